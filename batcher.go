@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// batchEntry is a pending record with its formatted message.
+type batchEntry struct {
+	rec     Record
+	message string
+}
+
+// batcher collects the records of a handler and passes them in batches to a
+// send function in the background, whenever a batch is full, the flush
+// interval elapses or the batcher is closed. It is shared by the handlers
+// sending batches to a remote service.
+type batcher struct {
+	name  string // name is the handler name used in the error reports
+	split func(pending []batchEntry) (n int, full bool)
+	send  func(batch []batchEntry) error
+
+	mu      sync.Mutex
+	pending []batchEntry
+	closed  bool
+	flushMu sync.Mutex // flushMu serializes the flushes to keep the batches in order
+	flushCh chan struct{}
+	closeCh chan struct{}
+	done    chan struct{}
+	once    sync.Once
+}
+
+// newBatcher creates a new batcher sending the pending records at least
+// every flushInterval. A non-positive flushInterval disables the periodic
+// sends.
+//
+// split returns the number of pending records of the next batch and whether
+// it is a full batch. send is never called concurrently.
+func newBatcher(name string, flushInterval time.Duration,
+	split func([]batchEntry) (int, bool), send func([]batchEntry) error) *batcher {
+	b := &batcher{
+		name:    name,
+		split:   split,
+		send:    send,
+		flushCh: make(chan struct{}, 1),
+		closeCh: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	go b.process(flushInterval)
+
+	return b
+}
+
+// splitCount returns the next batch of up to size pending records. A
+// non-positive size sends every record as soon as it is added.
+func splitCount(pending []batchEntry, size int) (n int, full bool) {
+	n = len(pending)
+	if size > 0 && n > size {
+		n = size
+	}
+	return n, n >= size
+}
+
+// add adds the record to the pending batch and wakes up the background
+// send when the batch is full. It fails once the batcher is closed.
+func (b *batcher) add(e batchEntry) error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return fmt.Errorf("%s is closed", b.name)
+	}
+	b.pending = append(b.pending, e)
+	_, full := b.split(b.pending)
+	b.mu.Unlock()
+
+	if full {
+		select {
+		case b.flushCh <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// close sends the pending records and stops the background sends. Closing
+// it again has no effect.
+func (b *batcher) close() {
+	b.once.Do(func() {
+		b.mu.Lock()
+		b.closed = true
+		b.mu.Unlock()
+
+		close(b.closeCh)
+		<-b.done
+	})
+}
+
+// process sends the pending batch whenever it is full, the flush interval
+// elapses or the batcher is closed.
+func (b *batcher) process(flushInterval time.Duration) {
+	defer close(b.done)
+
+	var tick <-chan time.Time
+	if flushInterval > 0 {
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-tick:
+			b.flush(true)
+		case <-b.flushCh:
+			b.flush(false)
+		case <-b.closeCh:
+			b.flush(true)
+			return
+		}
+	}
+}
+
+// flush sends the pending records in batches and returns the error of the
+// last failed send. A partial batch is only sent when all is set.
+func (b *batcher) flush(all bool) (err error) {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	for {
+		b.mu.Lock()
+		n, full := b.split(b.pending)
+		if !full && !all {
+			n = 0
+		}
+		batch := b.pending[:n:n]
+		b.pending = b.pending[n:]
+		b.mu.Unlock()
+
+		if n == 0 {
+			return err
+		}
+
+		if serr := b.send(batch); serr != nil {
+			fmt.Fprintf(os.Stderr, "%s dropping %d records: %s\n", b.name, len(batch), serr)
+			err = serr
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// batchRecorder records the sizes of the batches sent to it.
+type batchRecorder struct {
+	mu    sync.Mutex
+	sizes []int
+	err   error
+}
+
+func (r *batchRecorder) send(batch []batchEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sizes = append(r.sizes, len(batch))
+	return r.err
+}
+
+func (r *batchRecorder) sent() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int(nil), r.sizes...)
+}
+
+func TestBatcher_Split(t *testing.T) {
+	r := &batchRecorder{}
+	b := newBatcher("test", time.Hour, func(pending []batchEntry) (int, bool) {
+		return splitCount(pending, 2)
+	}, r.send)
+
+	for i := 0; i < 5; i++ {
+		b.add(batchEntry{message: "record"})
+	}
+	deadline := time.Now().Add(time.Second)
+	for len(r.sent()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	b.close()
+
+	if sizes := r.sent(); len(sizes) != 3 || sizes[0] != 2 || sizes[1] != 2 || sizes[2] != 1 {
+		t.Errorf("expected two full batches and the rest on close got %v", sizes)
+	}
+}
+
+func TestBatcher_Close(t *testing.T) {
+	r := &batchRecorder{err: errors.New("unavailable")}
+	b := newBatcher("test", 0, func(pending []batchEntry) (int, bool) {
+		return splitCount(pending, 10)
+	}, r.send)
+
+	b.add(batchEntry{message: "record"})
+	if err := b.flush(true); err == nil {
+		t.Error("expected the send error")
+	}
+	b.close()
+	b.close()
+
+	if err := b.add(batchEntry{message: "record"}); err == nil {
+		t.Error("expected an error adding to a closed batcher")
+	}
+	if sizes := r.sent(); len(sizes) != 1 {
+		t.Errorf("expected a single batch got %v", sizes)
+	}
+}
@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// BufferedFileHandler is a handler implementation that appends the logger
+// output to a file through a buffer, saving a write per record.
+//
+// The buffer is written to the file when it is full, every flush interval
+// and when the handler is flushed or closed.
+type BufferedFileHandler struct {
+	*BaseHandler
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	w       *bufio.Writer
+	closeCh chan struct{}
+	done    chan struct{}
+	once    sync.Once // once guards Close
+}
+
+// NewBufferedFileHandler creates a new buffered file handler appending to the
+// file at path with a buffer of bufSize bytes written out at least every
+// flushInterval. A non-positive flushInterval disables the periodic writes,
+// the buffer is then written when full, flushed or closed. The file is
+// created if it does not exist.
+func NewBufferedFileHandler(path string, bufSize int, flushInterval time.Duration) (*BufferedFileHandler, error) {
+	f, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &BufferedFileHandler{
+		BaseHandler: NewBaseHandler(),
+		path:        path,
+		file:        f,
+		w:           bufio.NewWriterSize(f, bufSize),
+		closeCh:     make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+
+	go h.process(flushInterval)
+
+	return h, nil
+}
+
+// process flushes the buffer every flushInterval until the handler is closed.
+func (h *BufferedFileHandler) process(flushInterval time.Duration) {
+	defer close(h.done)
+
+	// A nil channel never fires, disabling the periodic flushes.
+	var tick <-chan time.Time
+	if flushInterval > 0 {
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-tick:
+			if err := h.Flush(); err != nil {
+				fmt.Fprintf(os.Stderr, "BufferedFileHandler can not flush %s: %s\n", h.path, err)
+			}
+		case <-h.closeCh:
+			return
+		}
+	}
+}
+
+// Handle writes the formatted record to the buffer.
+func (h *BufferedFileHandler) Handle(rec *Record) error {
+	message := h.BaseHandler.FilterAndFormat(rec)
+	if message == "" {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.w.WriteString(line(message))
+	return err
+}
+
+// Flush writes the buffered records to the file.
+func (h *BufferedFileHandler) Flush() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.w.Flush()
+}
+
+// Close flushes the buffer and closes the underlying file. It may be called
+// several times.
+func (h *BufferedFileHandler) Close() {
+	h.once.Do(func() {
+		close(h.closeCh)
+		<-h.done
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if err := h.w.Flush(); err != nil {
+			fmt.Fprintf(os.Stderr, "BufferedFileHandler can not flush %s: %s\n", h.path, err)
+		}
+		h.file.Close()
+	})
+}
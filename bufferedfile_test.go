@@ -0,0 +1,102 @@
+package logger
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBufferedFileHandler_FlushInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	h, err := NewBufferedFileHandler(path, 4096, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.SetFormatter(&messageFormatter{})
+
+	l := NewLogger("buffered")
+	l.SetHandler(h)
+	l.Info("first")
+	l.Info("second")
+
+	var b []byte
+	deadline := time.Now().Add(time.Second)
+	for len(b) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		if b, err = ioutil.ReadFile(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if string(b) != "first\nsecond\n" {
+		t.Errorf("expected the records on disk before close got %q", b)
+	}
+}
+
+func TestBufferedFileHandler_BufferSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	h, err := NewBufferedFileHandler(path, 16, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.SetFormatter(&messageFormatter{})
+
+	h.Handle(&Record{Format: "short\n"})
+	if b, _ := ioutil.ReadFile(path); len(b) != 0 {
+		t.Errorf("expected the record to be buffered got %q", b)
+	}
+
+	h.Handle(&Record{Format: strings.Repeat("x", 20) + "\n"})
+	if b, _ := ioutil.ReadFile(path); !strings.HasPrefix(string(b), "short\n") {
+		t.Errorf("expected the full buffer to be written got %q", b)
+	}
+
+	h.Close()
+	if b, _ := ioutil.ReadFile(path); string(b) != "short\n"+strings.Repeat("x", 20)+"\n" {
+		t.Errorf("expected the buffer to be written on close got %q", b)
+	}
+}
+
+func TestBufferedFileHandler_NoFlushInterval(t *testing.T) {
+	for _, interval := range []time.Duration{0, -time.Second} {
+		path := filepath.Join(t.TempDir(), "app.log")
+
+		h, err := NewBufferedFileHandler(path, 4096, interval)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.SetFormatter(&messageFormatter{})
+		h.Handle(&Record{Format: "buffered\n"})
+
+		time.Sleep(10 * time.Millisecond)
+		if b, _ := ioutil.ReadFile(path); len(b) != 0 {
+			t.Errorf("interval %s: expected no periodic write got %q", interval, b)
+		}
+
+		h.Close()
+		if b, _ := ioutil.ReadFile(path); string(b) != "buffered\n" {
+			t.Errorf("interval %s: expected the buffer to be written on close got %q", interval, b)
+		}
+	}
+}
+
+func TestBufferedFileHandler_CloseTwice(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	h, err := NewBufferedFileHandler(path, 4096, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.SetFormatter(&messageFormatter{})
+	h.Handle(&Record{Format: "closed\n"})
+
+	h.Close()
+	h.Close()
+	if b, _ := ioutil.ReadFile(path); string(b) != "closed\n" {
+		t.Errorf("expected the record written once got %q", b)
+	}
+}
@@ -0,0 +1,94 @@
+package logger
+
+import (
+	"container/list"
+	"os"
+	"sync"
+)
+
+// ByNameFileHandler is a handler implementation appending the output of each
+// logger to its own file. The path of the file is given by a function of the
+// logger name. Files are opened on first use and at most maxOpen of them are
+// kept open, closing the least recently used ones.
+type ByNameFileHandler struct {
+	*BaseHandler
+	path    func(name string) string
+	maxOpen int
+
+	mu    sync.Mutex
+	lru   *list.List               // lru holds the open files, most recently used first
+	files map[string]*list.Element // files maps the paths to their lru elements
+}
+
+// byNameFile is an open file of a ByNameFileHandler.
+type byNameFile struct {
+	path string
+	file *os.File
+}
+
+// NewByNameFileHandler creates a new handler appending the records of each
+// logger to the file at path(name), keeping at most maxOpen files open.
+func NewByNameFileHandler(path func(name string) string, maxOpen int) *ByNameFileHandler {
+	if maxOpen < 1 {
+		maxOpen = 1
+	}
+	return &ByNameFileHandler{
+		BaseHandler: NewBaseHandler(),
+		path:        path,
+		maxOpen:     maxOpen,
+		lru:         list.New(),
+		files:       make(map[string]*list.Element),
+	}
+}
+
+// Handle writes the formatted record to the file of its logger.
+func (h *ByNameFileHandler) Handle(rec *Record) error {
+	message := h.BaseHandler.FilterAndFormat(rec)
+	if message == "" {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	f, err := h.file(h.path(rec.LoggerName))
+	if err != nil {
+		return err
+	}
+	_, err = f.WriteString(line(message))
+	return err
+}
+
+// file returns the open file at path, opening it and closing the least
+// recently used file if needed.
+func (h *ByNameFileHandler) file(path string) (*os.File, error) {
+	if e, ok := h.files[path]; ok {
+		h.lru.MoveToFront(e)
+		return e.Value.(*byNameFile).file, nil
+	}
+
+	f, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for h.lru.Len() >= h.maxOpen {
+		oldest := h.lru.Remove(h.lru.Back()).(*byNameFile)
+		delete(h.files, oldest.path)
+		oldest.file.Close()
+	}
+	h.files[path] = h.lru.PushFront(&byNameFile{path: path, file: f})
+	return f, nil
+}
+
+// Close closes all the open files.
+func (h *ByNameFileHandler) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for e := h.lru.Front(); e != nil; e = e.Next() {
+		e.Value.(*byNameFile).file.Close()
+	}
+	h.lru.Init()
+	h.files = make(map[string]*list.Element)
+}
@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestByNameFileHandler_Handle(t *testing.T) {
+	dir := t.TempDir()
+	h := NewByNameFileHandler(func(name string) string {
+		return filepath.Join(dir, name+".log")
+	}, 2)
+	h.SetFormatter(&messageFormatter{})
+
+	names := []string{"db", "http", "cache"}
+	for i := 0; i < 3; i++ {
+		for _, name := range names {
+			l := NewLogger(name)
+			l.SetHandler(h)
+			l.Info("%s %d", name, i)
+		}
+	}
+	if n := h.lru.Len(); n != 2 {
+		t.Errorf("expected 2 open files got %d", n)
+	}
+	h.Close()
+
+	for _, name := range names {
+		b, err := ioutil.ReadFile(filepath.Join(dir, name+".log"))
+		if err != nil {
+			t.Errorf("missing file: %s", err)
+			continue
+		}
+		expected := name + " 0\n" + name + " 1\n" + name + " 2\n"
+		if string(b) != expected {
+			t.Errorf("expected %q in %s.log got %q", expected, name, b)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package logger
+
+// ChainFormatter post-processes the output of another formatter, e.g. to tag
+// or redact the formatted records without reimplementing the formatting.
+type ChainFormatter struct {
+	// Base formats the records. DefaultFormatter is used when it is nil.
+	Base Formatter
+
+	// Transform is applied to the output of Base. The output is returned
+	// as is when it is nil.
+	Transform func(string) string
+}
+
+// NewChainFormatter creates a new formatter applying transform to the output
+// of base.
+func NewChainFormatter(base Formatter, transform func(string) string) *ChainFormatter {
+	return &ChainFormatter{Base: base, Transform: transform}
+}
+
+func (f *ChainFormatter) Format(rec *Record) string {
+	base := f.Base
+	if base == nil {
+		base = DefaultFormatter
+	}
+	s := base.Format(rec)
+	if f.Transform == nil {
+		return s
+	}
+	return f.Transform(s)
+}
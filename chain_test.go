@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChainFormatter_Format(t *testing.T) {
+	rec := &Record{
+		Format:     "user %s logged in",
+		Args:       []interface{}{"bob"},
+		LoggerName: "app",
+		Level:      INFO,
+		Time:       time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+	}
+	tag := func(s string) string { return "[api] " + s }
+
+	for _, base := range []Formatter{&TextFormatter{}, &JSONFormatter{}, &LogfmtFormatter{}} {
+		expected := "[api] " + base.Format(rec)
+		if out := NewChainFormatter(base, tag).Format(rec); out != expected {
+			t.Errorf("%T: expected %q got %q", base, expected, out)
+		}
+	}
+
+	if out, expected := (&ChainFormatter{Transform: strings.ToUpper}).Format(rec), strings.ToUpper(DefaultFormatter.Format(rec)); out != expected {
+		t.Errorf("expected the default formatter output transformed %q got %q", expected, out)
+	}
+	if out, expected := (&ChainFormatter{Base: &LogfmtFormatter{}}).Format(rec), (&LogfmtFormatter{}).Format(rec); out != expected {
+		t.Errorf("expected the base output without transform %q got %q", expected, out)
+	}
+}
+
+func TestChainFormatter_Handler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewWriterHandler(&buf)
+	h.SetFormatter(NewChainFormatter(&LogfmtFormatter{}, func(s string) string {
+		return strings.Replace(s, "secret", "******", -1)
+	}))
+
+	l := NewLogger("chain")
+	l.SetHandler(h)
+	l.Info("token secret")
+
+	if out := buf.String(); strings.Contains(out, "secret") || !strings.HasSuffix(out, `msg="token ******"`+"\n") {
+		t.Errorf("expected the transformed line got %q", out)
+	}
+}
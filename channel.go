@@ -0,0 +1,68 @@
+package logger
+
+import "sync/atomic"
+
+// ChannelHandler mirrors records to a channel, e.g. to stream them to a live
+// tail endpoint, and passes them to an inner handler.
+//
+// Records are sent without blocking, they are dropped from the channel when
+// it is full so that a slow reader never stalls logging. The inner handler
+// gets every record regardless.
+type ChannelHandler struct {
+	dropped uint64 // dropped counts dropped records, kept first for 64-bit alignment
+	inner   Handler
+	ch      chan<- *Record
+}
+
+var _ Handler = (*ChannelHandler)(nil)
+
+// NewChannelHandler creates a new handler sending a copy of each record to ch
+// and passing it to inner. A nil inner handler only feeds the channel.
+//
+// The channel is never closed by the handler.
+func NewChannelHandler(ch chan<- *Record, inner Handler) *ChannelHandler {
+	if inner == nil {
+		inner = DiscardHandler
+	}
+	return &ChannelHandler{inner: inner, ch: ch}
+}
+
+// SetLevel sets logger level for inner handler.
+func (h *ChannelHandler) SetLevel(l level) {
+	h.inner.SetLevel(l)
+}
+
+// SetFormatter sets logger formatter for inner handler.
+func (h *ChannelHandler) SetFormatter(f Formatter) {
+	h.inner.SetFormatter(f)
+}
+
+// Handle sends a copy of rec to the channel, dropping it if the channel is
+// full, and passes rec to the inner handler.
+func (h *ChannelHandler) Handle(rec *Record) error {
+	c := new(Record)
+	*c = *rec
+
+	select {
+	case h.ch <- c:
+	default:
+		atomic.AddUint64(&h.dropped, 1)
+	}
+
+	return h.inner.Handle(rec)
+}
+
+// Dropped reports the number of records dropped because the channel was full.
+func (h *ChannelHandler) Dropped() uint64 {
+	return atomic.LoadUint64(&h.dropped)
+}
+
+// Flush flushes the inner handler.
+func (h *ChannelHandler) Flush() error {
+	return h.inner.Flush()
+}
+
+// Close closes the inner handler.
+func (h *ChannelHandler) Close() {
+	h.inner.Close()
+}
@@ -0,0 +1,52 @@
+package logger
+
+import "testing"
+
+func TestChannelHandler_Handle(t *testing.T) {
+	ch := make(chan *Record, 2)
+	r := NewLogRecorder()
+	h := NewChannelHandler(ch, r)
+
+	l := NewLogger("tail")
+	l.SetHandler(h)
+	l.Info("first")
+	l.Info("second")
+	l.Info("third")
+
+	for _, expected := range []string{"first", "second"} {
+		rec := <-ch
+		if got := rec.Message(); got != expected {
+			t.Errorf("expected %q got %q", expected, got)
+		}
+	}
+	select {
+	case rec := <-ch:
+		t.Errorf("expected third record to be dropped got %q", rec.Message())
+	default:
+	}
+	if n := h.Dropped(); n != 1 {
+		t.Errorf("expected 1 dropped record got %d", n)
+	}
+
+	if n := len(r.Records["tail"]); n != 3 {
+		t.Errorf("expected 3 records for inner handler got %d", n)
+	}
+
+	h.SetLevel(ERROR)
+	h.Close()
+	if r.Level != ERROR || !r.Closed {
+		t.Errorf("level and close are not propagated")
+	}
+}
+
+func TestChannelHandler_NilInner(t *testing.T) {
+	ch := make(chan *Record, 1)
+	l := NewLogger("tail")
+	l.SetHandler(NewChannelHandler(ch, nil))
+	l.Warning("message %d", 1)
+
+	rec := <-ch
+	if rec.Level != WARNING || rec.Message() != "message 1" {
+		t.Errorf("unexpected record %+v", rec)
+	}
+}
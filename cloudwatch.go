@@ -0,0 +1,178 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PutLogEvents limits of CloudWatch Logs.
+const (
+	cloudWatchMaxEvents     = 10000
+	cloudWatchMaxBatchBytes = 1048576
+	cloudWatchEventOverhead = 26 // cloudWatchEventOverhead is counted per event in the batch size
+)
+
+// CloudWatchEvent is a log event of CloudWatch Logs.
+type CloudWatchEvent struct {
+	Message   string
+	Timestamp int64 // Timestamp is in milliseconds since the epoch
+}
+
+// CloudWatchClient is the subset of the CloudWatch Logs API used by
+// CloudWatchHandler. It keeps this package free of the AWS SDK: wrap the SDK
+// client to implement it.
+type CloudWatchClient interface {
+	// PutLogEvents uploads the events to the stream and returns the next
+	// sequence token. A nil token is passed for the first upload to a
+	// stream. An InvalidSequenceTokenError must be returned when the token
+	// is rejected.
+	PutLogEvents(group, stream string, events []CloudWatchEvent, sequenceToken *string) (nextSequenceToken *string, err error)
+
+	// CreateLogGroup creates the log group. It must return nil if the
+	// group already exists.
+	CreateLogGroup(group string) error
+
+	// CreateLogStream creates the log stream. It must return nil if the
+	// stream already exists.
+	CreateLogStream(group, stream string) error
+}
+
+// InvalidSequenceTokenError is returned by CloudWatchClient.PutLogEvents
+// when the sequence token is not the expected one.
+type InvalidSequenceTokenError struct {
+	ExpectedSequenceToken *string
+}
+
+func (e *InvalidSequenceTokenError) Error() string {
+	return "invalid sequence token"
+}
+
+// CloudWatchHandler sends the logger output to a stream of Amazon
+// CloudWatch Logs.
+//
+// Records are collected until BatchSize records are pending or the flush
+// interval elapses, and are then uploaded with PutLogEvents in batches
+// within the limits of the API. The sequence token is tracked between
+// uploads and refreshed when it is rejected.
+type CloudWatchHandler struct {
+	*BaseHandler
+
+	// BatchSize is the number of records that triggers an upload. It is
+	// capped to the 10000 events of a PutLogEvents call.
+	BatchSize int
+
+	client  CloudWatchClient
+	group   string
+	stream  string
+	token   *string // token is guarded by the flushes of batcher
+	batcher *batcher
+}
+
+// NewCloudWatchHandler creates a new CloudWatch handler uploading batches
+// of up to batchSize records to the log stream at least every
+// flushInterval. A non-positive flushInterval disables the periodic
+// uploads. The log group and stream are created first when create is set.
+func NewCloudWatchHandler(client CloudWatchClient, group, stream string, batchSize int,
+	flushInterval time.Duration, create bool) (*CloudWatchHandler, error) {
+	if create {
+		if err := client.CreateLogGroup(group); err != nil {
+			return nil, fmt.Errorf("CloudWatchHandler can not create log group %s: %s", group, err)
+		}
+		if err := client.CreateLogStream(group, stream); err != nil {
+			return nil, fmt.Errorf("CloudWatchHandler can not create log stream %s: %s", stream, err)
+		}
+	}
+
+	h := &CloudWatchHandler{
+		BaseHandler: NewBaseHandler(),
+		BatchSize:   batchSize,
+		client:      client,
+		group:       group,
+		stream:      stream,
+	}
+	h.batcher = newBatcher("CloudWatchHandler", flushInterval, h.split, h.put)
+	return h, nil
+}
+
+// Handle adds the record to the pending batch. Uploading the batch happens
+// in the background, its failures are reported on stderr or by Flush.
+func (h *CloudWatchHandler) Handle(rec *Record) error {
+	message := h.BaseHandler.FilterAndFormat(rec)
+	if message == "" {
+		return nil
+	}
+
+	message = strings.TrimSuffix(message, "\n")
+	if len(message)+cloudWatchEventOverhead > cloudWatchMaxBatchBytes {
+		return fmt.Errorf("CloudWatchHandler can not send record of %d bytes", len(message))
+	}
+	return h.batcher.add(batchEntry{rec: *rec, message: message})
+}
+
+// Flush uploads the pending records and returns the error of the last
+// failed upload.
+func (h *CloudWatchHandler) Flush() error {
+	return h.batcher.flush(true)
+}
+
+// Close uploads the pending batch and stops the handler. Closing it again
+// has no effect.
+func (h *CloudWatchHandler) Close() {
+	h.batcher.close()
+}
+
+// batchSize returns the number of events of a full batch.
+func (h *CloudWatchHandler) batchSize() int {
+	if h.BatchSize <= 0 || h.BatchSize > cloudWatchMaxEvents {
+		return cloudWatchMaxEvents
+	}
+	return h.BatchSize
+}
+
+// split returns the next batch within the size limits of PutLogEvents.
+func (h *CloudWatchHandler) split(pending []batchEntry) (n int, full bool) {
+	size := 0
+	for n < len(pending) && !full {
+		size += len(pending[n].message) + cloudWatchEventOverhead
+		if size > cloudWatchMaxBatchBytes {
+			return n, true
+		}
+		n++
+		full = n == h.batchSize()
+	}
+	return n, full
+}
+
+// put uploads the batch, retrying once with the expected sequence token if
+// the current one is rejected.
+func (h *CloudWatchHandler) put(batch []batchEntry) error {
+	events := make([]CloudWatchEvent, len(batch))
+	for i, e := range batch {
+		events[i] = CloudWatchEvent{
+			Message:   e.message,
+			Timestamp: e.rec.Time.UnixNano() / int64(time.Millisecond),
+		}
+	}
+
+	// The events of a batch must be in chronological order.
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Timestamp < events[j].Timestamp
+	})
+
+	for retried := false; ; retried = true {
+		token, err := h.client.PutLogEvents(h.group, h.stream, events, h.token)
+		if err == nil {
+			h.token = token
+			return nil
+		}
+
+		var terr *InvalidSequenceTokenError
+		if retried || !errors.As(err, &terr) {
+			return err
+		}
+		h.token = terr.ExpectedSequenceToken
+	}
+}
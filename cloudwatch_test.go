@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockCloudWatch records the uploads and checks the sequence tokens.
+type mockCloudWatch struct {
+	mu       sync.Mutex
+	groups   []string
+	streams  []string
+	batches  [][]CloudWatchEvent
+	tokens   []*string
+	expected *string // expected is the next sequence token
+	serial   int
+}
+
+func (m *mockCloudWatch) PutLogEvents(group, stream string, events []CloudWatchEvent, token *string) (*string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.tokens = append(m.tokens, token)
+	if (token == nil) != (m.expected == nil) || token != nil && *token != *m.expected {
+		return nil, &InvalidSequenceTokenError{ExpectedSequenceToken: m.expected}
+	}
+
+	m.batches = append(m.batches, append([]CloudWatchEvent(nil), events...))
+	m.serial++
+	next := strings.Repeat("t", m.serial)
+	m.expected = &next
+	return &next, nil
+}
+
+func (m *mockCloudWatch) CreateLogGroup(group string) error {
+	m.groups = append(m.groups, group)
+	return nil
+}
+
+func (m *mockCloudWatch) CreateLogStream(group, stream string) error {
+	m.streams = append(m.streams, group+"/"+stream)
+	return nil
+}
+
+func TestCloudWatchHandler_Handle(t *testing.T) {
+	m := &mockCloudWatch{}
+	h, err := NewCloudWatchHandler(m, "group", "stream", 2, time.Hour, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.SetFormatter(&messageFormatter{})
+
+	if len(m.groups) != 1 || len(m.streams) != 1 || m.streams[0] != "group/stream" {
+		t.Errorf("expected the group and stream to be created got %v %v", m.groups, m.streams)
+	}
+
+	now := time.Unix(1500000000, 0)
+	h.Handle(&Record{Format: "second\n", Level: INFO, Time: now.Add(time.Second)})
+	h.Handle(&Record{Format: "first\n", Level: INFO, Time: now})
+	h.Handle(&Record{Format: "third\n", Level: INFO, Time: now.Add(2 * time.Second)})
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	h.Close()
+
+	if len(m.batches) != 2 {
+		t.Fatalf("expected 2 batches got %d", len(m.batches))
+	}
+	expected := [][]string{{"first", "second"}, {"third"}}
+	for i, batch := range m.batches {
+		for j, e := range batch {
+			if e.Message != expected[i][j] {
+				t.Errorf("expected message %q got %q", expected[i][j], e.Message)
+			}
+		}
+	}
+	if ts := m.batches[0][0].Timestamp; ts != 1500000000000 {
+		t.Errorf("expected timestamp in milliseconds got %d", ts)
+	}
+	if m.tokens[0] != nil || m.tokens[1] == nil || *m.tokens[1] != "t" {
+		t.Errorf("expected the sequence token of the first upload to be passed to the second")
+	}
+}
+
+func TestCloudWatchHandler_InvalidSequenceToken(t *testing.T) {
+	token := "other"
+	m := &mockCloudWatch{expected: &token, serial: 5}
+	h, err := NewCloudWatchHandler(m, "group", "stream", 10, time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.SetFormatter(&messageFormatter{})
+
+	if len(m.groups) != 0 || len(m.streams) != 0 {
+		t.Errorf("expected no creation got %v %v", m.groups, m.streams)
+	}
+
+	h.Handle(&Record{Format: "message\n", Level: INFO})
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.batches) != 1 || len(m.tokens) != 2 || *m.tokens[1] != "other" {
+		t.Errorf("expected a retry with the expected token got %d batches and tokens %v", len(m.batches), m.tokens)
+	}
+}
+
+func TestCloudWatchHandler_Limits(t *testing.T) {
+	m := &mockCloudWatch{}
+	h, err := NewCloudWatchHandler(m, "group", "stream", 0, time.Hour, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.SetFormatter(&messageFormatter{})
+
+	if err := h.Handle(&Record{Format: strings.Repeat("x", cloudWatchMaxBatchBytes) + "\n", Level: INFO}); err == nil {
+		t.Errorf("expected an error for a record larger than a batch")
+	}
+
+	// 12000 events exceed the event count limit, 3 large ones the size limit.
+	for i := 0; i < 12000; i++ {
+		h.Handle(&Record{Format: "small\n", Level: INFO})
+	}
+	large := strings.Repeat("x", 400000) + "\n"
+	for i := 0; i < 3; i++ {
+		h.Handle(&Record{Format: large, Level: INFO})
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	var events int
+	for _, batch := range m.batches {
+		size := 0
+		for _, e := range batch {
+			size += len(e.Message) + cloudWatchEventOverhead
+		}
+		if len(batch) > cloudWatchMaxEvents || size > cloudWatchMaxBatchBytes {
+			t.Errorf("batch of %d events and %d bytes exceeds the limits", len(batch), size)
+		}
+		events += len(batch)
+	}
+	if events != 12003 {
+		t.Errorf("expected 12003 events got %d", events)
+	}
+}
+
+func TestCloudWatchHandler_CloseTwice(t *testing.T) {
+	m := &mockCloudWatch{}
+	h, err := NewCloudWatchHandler(m, "group", "stream", 10, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.SetFormatter(&messageFormatter{})
+
+	h.Handle(&Record{Format: "record\n", Level: INFO})
+	h.Close()
+	h.Close()
+
+	if len(m.batches) != 1 || len(m.batches[0]) != 1 {
+		t.Errorf("expected the record to be uploaded on close got %v", m.batches)
+	}
+	if err := h.Handle(&Record{Format: "late\n", Level: INFO}); err == nil {
+		t.Error("expected an error handling a record after close")
+	}
+}
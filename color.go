@@ -0,0 +1,137 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ColorFormatter formats records like TextFormatter, wrapping the level name
+// in ANSI escape codes of the level's color.
+type ColorFormatter struct {
+	TextFormatter
+
+	// Colorize enables the escape codes. Without it the output is identical
+	// to TextFormatter.
+	Colorize bool
+
+	// FullLine colors the whole line instead of the level name only.
+	FullLine bool
+}
+
+// NewColorFormatter creates a new color formatter for output written to out.
+// Colors are enabled when out is a terminal and the NO_COLOR environment
+// variable is not set, or when force is set.
+func NewColorFormatter(out io.Writer, force bool) *ColorFormatter {
+	return &ColorFormatter{
+		Colorize: force || colorEnabled(out),
+	}
+}
+
+func (f *ColorFormatter) Format(rec *Record) string {
+	name := rec.Level.String()
+	padding := ""
+	if len(name) < 8 {
+		padding = strings.Repeat(" ", 8-len(name))
+	}
+
+	if !f.Colorize {
+		return f.TextFormatter.format(rec, name+padding)
+	}
+
+	c := levelColor(rec.Level)
+	if !f.FullLine {
+		return f.TextFormatter.format(rec, colorize(c, name)+padding)
+	}
+
+	return colorize(c, f.TextFormatter.format(rec, name+padding))
+}
+
+// colorize wraps s in the escape codes of color c.
+func colorize(c color, s string) string {
+	return fmt.Sprintf("\033[%dm%s\033[0m", c, s)
+}
+
+// colorEnabled reports whether colors should be used for output written to w
+// by default, which is when w is a terminal and NO_COLOR is not set.
+func colorEnabled(w io.Writer) bool {
+	return os.Getenv("NO_COLOR") == "" && isTerminal(w)
+}
+
+// isTerminal reports whether w is a file attached to a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// ColorWriter is an io.Writer passing output to an underlying writer and
+// stripping the ANSI escape sequences when colors are disabled for it, so
+// output colorized unconditionally stays clean in files and pipes.
+type ColorWriter struct {
+	mu    sync.Mutex
+	w     io.Writer
+	strip bool
+	state int // state is the position in an escape sequence spanning writes
+}
+
+// States of ColorWriter inside escape sequences.
+const (
+	ansiText = iota
+	ansiEscape
+	ansiCSI
+)
+
+// NewColorWriter creates a new writer to w, stripping the escape sequences
+// unless w is a terminal and NO_COLOR is not set.
+func NewColorWriter(w io.Writer) *ColorWriter {
+	return &ColorWriter{w: w, strip: !colorEnabled(w)}
+}
+
+// Write writes p to the underlying writer, without the escape sequences if
+// they are stripped. Sequences may be split across writes.
+func (w *ColorWriter) Write(p []byte) (int, error) {
+	if !w.strip {
+		return w.w.Write(p)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	b := make([]byte, 0, len(p))
+	for _, c := range p {
+		switch w.state {
+		case ansiText:
+			if c == '\033' {
+				w.state = ansiEscape
+			} else {
+				b = append(b, c)
+			}
+		case ansiEscape:
+			if c == '[' {
+				w.state = ansiCSI
+			} else {
+				// A two byte sequence, e.g. ESC c.
+				w.state = ansiText
+			}
+		case ansiCSI:
+			// Parameters and intermediates until the final byte.
+			if c >= 0x40 && c <= 0x7e {
+				w.state = ansiText
+			}
+		}
+	}
+
+	if _, err := w.w.Write(b); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
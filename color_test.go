@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestColorFormatter_Format(t *testing.T) {
+	f := NewColorFormatter(&bytes.Buffer{}, true)
+
+	for l, name := range levelNames {
+		rec := &Record{Format: "message\n", Level: l}
+		out := f.Format(rec)
+
+		colored := fmt.Sprintf("\033[%dm%s\033[0m", levelColors[l], name)
+		if !strings.Contains(out, colored) {
+			t.Errorf("expected %q in %q", colored, out)
+		}
+		if !strings.HasSuffix(out, "] message") {
+			t.Errorf("message should not be colored: %q", out)
+		}
+	}
+}
+
+func TestColorFormatter_FullLine(t *testing.T) {
+	f := NewColorFormatter(&bytes.Buffer{}, true)
+	f.FullLine = true
+
+	out := f.Format(&Record{Format: "message\n", Level: ERROR})
+	if !strings.HasPrefix(out, "\033[31m") || !strings.HasSuffix(out, "message\033[0m") {
+		t.Errorf("expected the whole line to be colored got %q", out)
+	}
+}
+
+func TestColorFormatter_NotTerminal(t *testing.T) {
+	f := NewColorFormatter(&bytes.Buffer{}, false)
+	rec := &Record{Format: "message\n", Level: ERROR}
+
+	if out := f.Format(rec); out != DefaultFormatter.Format(rec) {
+		t.Errorf("expected plain output got %q", out)
+	}
+}
+
+func TestColorFormatter_Pipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	if colorEnabled(w) {
+		t.Errorf("colors should be disabled for a pipe")
+	}
+
+	h := NewWriterHandler(w)
+	h.SetFormatter(NewColorFormatter(w, false))
+	h.Handle(&Record{Format: "message\n", Level: ERROR})
+	w.Close()
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(b, []byte("\033[")) {
+		t.Errorf("unexpected escape codes in %q", b)
+	}
+}
+
+func TestColorEnabled_NoColor(t *testing.T) {
+	defer os.Unsetenv("NO_COLOR")
+	os.Setenv("NO_COLOR", "1")
+
+	if colorEnabled(os.Stderr) {
+		t.Errorf("colors should be disabled with NO_COLOR")
+	}
+	if f := NewColorFormatter(os.Stderr, true); !f.Colorize {
+		t.Errorf("forced colors should ignore NO_COLOR")
+	}
+}
+
+func TestColorWriter_Write(t *testing.T) {
+	input := []string{
+		"\033[31mERROR\033[0m message\n",
+		"split \033[3", "3mWARN", "ING\033[0", "m\n",
+		"\033[1;32mbold green\033[0m\n",
+	}
+
+	var buf bytes.Buffer
+	w := NewColorWriter(&buf)
+	for _, s := range input {
+		if n, err := w.Write([]byte(s)); err != nil || n != len(s) {
+			t.Fatalf("expected %d bytes written got %d, %v", len(s), n, err)
+		}
+	}
+
+	expected := "ERROR message\nsplit WARNING\nbold green\n"
+	if out := buf.String(); out != expected {
+		t.Errorf("expected %q got %q", expected, out)
+	}
+}
+
+func TestColorWriter_Terminal(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewColorWriter(&buf)
+	w.strip = false // as for a terminal
+
+	colored := "\033[31mERROR\033[0m message\n"
+	w.Write([]byte(colored))
+	if out := buf.String(); out != colored {
+		t.Errorf("expected escape codes to be preserved got %q", out)
+	}
+}
@@ -1,7 +1,15 @@
 package logger
 
-import "fmt"
+import (
+	gocontext "context"
+	"fmt"
+	"io"
+	"strings"
+)
 
+// context is a Logger prepending a prefix to the messages of a logger. Its
+// methods check the level before building the prefixed format, so disabled
+// levels cost as little as with the logger.
 type context struct {
 	prefix string
 	logger
@@ -12,6 +20,12 @@ func (c *context) Fatal(format string, args ...interface{}) {
 	c.logger.Fatal(c.prefixFormat()+format, args...)
 }
 
+// FatalWithCode is equivalent to Critical() followed by a call to
+// os.Exit(code).
+func (c *context) FatalWithCode(code int, format string, args ...interface{}) {
+	c.logger.FatalWithCode(code, c.prefixFormat()+format, args...)
+}
+
 // Panic is equivalent to Critical() followed by a call to panic().
 func (c *context) Panic(format string, args ...interface{}) {
 	c.logger.Panic(c.prefixFormat()+format, args...)
@@ -20,42 +34,134 @@ func (c *context) Panic(format string, args ...interface{}) {
 // Critical sends a critical level log message to the handler. Arguments are
 // handled in the manner of fmt.Printf.
 func (c *context) Critical(format string, args ...interface{}) {
-	c.logger.Critical(c.prefixFormat()+format, args...)
+	if c.currentLevel() >= CRITICAL {
+		c.logger.Critical(c.prefixFormat()+format, args...)
+	}
 }
 
 // Error sends a error level log message to the handler. Arguments are handled
 // in the manner of fmt.Printf.
 func (c *context) Error(format string, args ...interface{}) {
-	c.logger.Error(c.prefixFormat()+format, args...)
+	if c.currentLevel() >= ERROR {
+		c.logger.Error(c.prefixFormat()+format, args...)
+	}
 }
 
 // Warning sends a warning level log message to the handler. Arguments are
 // handled in the manner of fmt.Printf.
 func (c *context) Warning(format string, args ...interface{}) {
-	c.logger.Warning(c.prefixFormat()+format, args...)
+	if c.currentLevel() >= WARNING {
+		c.logger.Warning(c.prefixFormat()+format, args...)
+	}
 }
 
 // Notice sends a notice level log message to the handler. Arguments are
 // handled in the manner of fmt.Printf.
 func (c *context) Notice(format string, args ...interface{}) {
-	c.logger.Notice(c.prefixFormat()+format, args...)
+	if c.currentLevel() >= NOTICE {
+		c.logger.Notice(c.prefixFormat()+format, args...)
+	}
 }
 
 // Info sends a info level log message to the handler. Arguments are handled in
 // the manner of fmt.Printf.
 func (c *context) Info(format string, args ...interface{}) {
-	c.logger.Info(c.prefixFormat()+format, args...)
+	if c.currentLevel() >= INFO {
+		c.logger.Info(c.prefixFormat()+format, args...)
+	}
 }
 
 // Debug sends a debug level log message to the handler. Arguments are handled
 // in the manner of fmt.Printf.
 func (c *context) Debug(format string, args ...interface{}) {
-	c.logger.Debug(c.prefixFormat()+format, args...)
+	if c.currentLevel() >= DEBUG {
+		c.logger.Debug(c.prefixFormat()+format, args...)
+	}
+}
+
+// Log sends a log message with the given level to the handler. Arguments are
+// handled in the manner of fmt.Printf.
+func (c *context) Log(level level, format string, args ...interface{}) {
+	if c.currentLevel() >= level {
+		c.logger.Log(level, c.prefixFormat()+format, args...)
+	}
+}
+
+// LogCtx sends a log message with the given level and the fields of ctx
+// to the handler. Arguments are handled in the manner of fmt.Printf.
+func (c *context) LogCtx(ctx gocontext.Context, level level, format string, args ...interface{}) {
+	if c.currentLevel() >= level {
+		c.logger.LogCtx(ctx, level, c.prefixFormat()+format, args...)
+	}
+}
+
+// CriticalCtx sends a critical level log message with the fields of ctx to the
+// handler. Arguments are handled in the manner of fmt.Printf.
+func (c *context) CriticalCtx(ctx gocontext.Context, format string, args ...interface{}) {
+	if c.currentLevel() >= CRITICAL {
+		c.logger.LogCtx(ctx, CRITICAL, c.prefixFormat()+format, args...)
+	}
+}
+
+// ErrorCtx sends a error level log message with the fields of ctx to the
+// handler. Arguments are handled in the manner of fmt.Printf.
+func (c *context) ErrorCtx(ctx gocontext.Context, format string, args ...interface{}) {
+	if c.currentLevel() >= ERROR {
+		c.logger.LogCtx(ctx, ERROR, c.prefixFormat()+format, args...)
+	}
+}
+
+// WarningCtx sends a warning level log message with the fields of ctx to the
+// handler. Arguments are handled in the manner of fmt.Printf.
+func (c *context) WarningCtx(ctx gocontext.Context, format string, args ...interface{}) {
+	if c.currentLevel() >= WARNING {
+		c.logger.LogCtx(ctx, WARNING, c.prefixFormat()+format, args...)
+	}
+}
+
+// NoticeCtx sends a notice level log message with the fields of ctx to the
+// handler. Arguments are handled in the manner of fmt.Printf.
+func (c *context) NoticeCtx(ctx gocontext.Context, format string, args ...interface{}) {
+	if c.currentLevel() >= NOTICE {
+		c.logger.LogCtx(ctx, NOTICE, c.prefixFormat()+format, args...)
+	}
+}
+
+// InfoCtx sends a info level log message with the fields of ctx to the
+// handler. Arguments are handled in the manner of fmt.Printf.
+func (c *context) InfoCtx(ctx gocontext.Context, format string, args ...interface{}) {
+	if c.currentLevel() >= INFO {
+		c.logger.LogCtx(ctx, INFO, c.prefixFormat()+format, args...)
+	}
+}
+
+// DebugCtx sends a debug level log message with the fields of ctx to the
+// handler. Arguments are handled in the manner of fmt.Printf.
+func (c *context) DebugCtx(ctx gocontext.Context, format string, args ...interface{}) {
+	if c.currentLevel() >= DEBUG {
+		c.logger.LogCtx(ctx, DEBUG, c.prefixFormat()+format, args...)
+	}
+}
+
+// Name returns the name of the logger followed by the prefixes of the context.
+func (c *context) Name() string {
+	return c.name + c.prefix
 }
 
 // New creates a new Logger from current context
 func (c *context) New(prefixes ...interface{}) Logger {
-	return newContext(c.logger, c.prefix, prefixes...)
+	return newContext(c.logger.clone(), c.prefix, prefixes...)
+}
+
+// WithPrefix creates a new Logger from current context with prefix appended
+func (c *context) WithPrefix(prefix string) Logger {
+	return withPrefix(c.logger.clone(), c.prefix, prefix)
+}
+
+// Writer returns an io.Writer logging each line written to it with the
+// prefixes of the context.
+func (c *context) Writer(lv level) io.Writer {
+	return &lineWriter{logger: c, level: lv}
 }
 
 func (c *context) prefixFormat() string {
@@ -83,3 +189,12 @@ func newContext(logger logger, initial string, prefixes ...interface{}) *context
 		logger: logger,
 	}
 }
+
+// withPrefix returns a context appending "[prefix]" to initial. The prefix is
+// escaped since it becomes part of the format string.
+func withPrefix(logger logger, initial string, prefix string) *context {
+	return &context{
+		prefix: initial + "[" + strings.Replace(prefix, "%", "%%", -1) + "]",
+		logger: logger,
+	}
+}
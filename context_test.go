@@ -0,0 +1,148 @@
+package logger
+
+import (
+	gocontext "context"
+	"fmt"
+	"testing"
+)
+
+func TestLogger_New(t *testing.T) {
+	r := NewLogRecorder()
+	parent := NewLogger("parent")
+	parent.SetLevel(DEBUG)
+	parent.SetHandler(r)
+
+	child := parent.New("request", 42)
+	child.Debug("child %s", "message")
+
+	grandchild := child.New("user", "bob")
+	grandchild.Info("grandchild message")
+
+	parent.Info("parent message")
+
+	recs := r.Records["parent"]
+	if len(recs) != 3 {
+		t.Fatalf("expected 3 records got %d", len(recs))
+	}
+
+	expected := []string{
+		"[request=42] child message\n",
+		"[request=42][user=bob] grandchild message\n",
+		"parent message\n",
+	}
+	for i, rec := range recs {
+		if msg := fmt.Sprintf(rec.Format, rec.Args...); msg != expected[i] {
+			t.Errorf("expected %q got %q", expected[i], msg)
+		}
+	}
+}
+
+func TestLogger_NewInheritsLevel(t *testing.T) {
+	r := NewLogRecorder()
+	parent := NewLogger("inherit")
+	parent.SetLevel(ERROR)
+	parent.SetHandler(r)
+
+	child := parent.New("child")
+	child.Info("filtered")
+	child.Error("kept")
+
+	if n := len(r.Records["inherit"]); n != 1 {
+		t.Errorf("expected 1 record got %d", n)
+	}
+}
+
+func TestLogger_WithPrefix(t *testing.T) {
+	r := NewLogRecorder()
+	base := NewLogger("prefix")
+	base.SetHandler(r)
+
+	a := base.WithPrefix("a")
+	b := a.WithPrefix("b")
+	b.Info("nested")
+	a.Info("single")
+	base.New("k", "v").WithPrefix("100%").Info("mixed")
+	base.Info("base")
+
+	expected := []string{
+		"[a][b] nested\n",
+		"[a] single\n",
+		"[k=v][100%] mixed\n",
+		"base\n",
+	}
+	recs := r.Records["prefix"]
+	if len(recs) != len(expected) {
+		t.Fatalf("expected %d records got %d", len(expected), len(recs))
+	}
+	for i, rec := range recs {
+		if msg := fmt.Sprintf(rec.Format, rec.Args...); msg != expected[i] {
+			t.Errorf("expected %q got %q", expected[i], msg)
+		}
+	}
+}
+
+func TestContext_DisabledLevel(t *testing.T) {
+	r := NewLogRecorder()
+	parent := NewLogger("disabled")
+	parent.SetLevel(ERROR)
+	parent.SetHandler(r)
+	child := parent.New("request", 42).WithPrefix("user")
+	ctx := gocontext.Background()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		child.Debug("disabled")
+		child.Info("disabled")
+		child.Log(WARNING, "disabled")
+		child.DebugCtx(ctx, "disabled")
+		child.LogCtx(ctx, NOTICE, "disabled")
+	})
+
+	if n := len(r.Records["disabled"]); n != 0 {
+		t.Errorf("expected no handler call got %d", n)
+	}
+	if allocs != 0 {
+		t.Errorf("expected the prefix not to be built got %v allocations", allocs)
+	}
+
+	child.Error("enabled")
+	if n := len(r.Records["disabled"]); n != 1 {
+		t.Errorf("expected 1 record got %d", n)
+	}
+}
+
+func TestLogger_Name(t *testing.T) {
+	l := NewLogger("db")
+	if name := l.Name(); name != "db" {
+		t.Errorf("expected %q got %q", "db", name)
+	}
+
+	child := l.New("request", 42)
+	if name := child.Name(); name != "db[request=42]" {
+		t.Errorf("expected %q got %q", "db[request=42]", name)
+	}
+	if name := child.WithPrefix("user").Name(); name != "db[request=42][user]" {
+		t.Errorf("expected %q got %q", "db[request=42][user]", name)
+	}
+	if name := l.Name(); name != "db" {
+		t.Errorf("expected the parent name to be unchanged got %q", name)
+	}
+}
+
+func TestLogger_NewConcurrentSetLevel(t *testing.T) {
+	l := NewLogger("race")
+	l.SetHandler(NewLogRecorder())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			l.SetLevel(DEBUG)
+			l.SetLevel(INFO)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		l.New("a").New("b")
+		l.WithPrefix("a").WithPrefix("b")
+	}
+	<-done
+}
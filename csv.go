@@ -0,0 +1,48 @@
+package logger
+
+import (
+	"encoding/csv"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvHeader holds the column names of CSVFormatter.
+var csvHeader = []string{"time", "level", "logger", "message", "file", "line", "fields"}
+
+// CSVFormatter formats records as RFC 4180 CSV rows with the columns time,
+// level, logger, message, file, line and fields, the structured fields of
+// the record as logfmt key=value pairs. Columns are quoted as needed so
+// messages may contain commas, quotes and newlines.
+type CSVFormatter struct{}
+
+// Header returns the header row naming the columns, to be written once
+// before the records. Like the records it has no trailing newline.
+func (f *CSVFormatter) Header() string {
+	return csvRow(csvHeader)
+}
+
+func (f *CSVFormatter) Format(rec *Record) string {
+	var line string
+	if rec.Line != 0 {
+		line = strconv.Itoa(rec.Line)
+	}
+	return csvRow([]string{
+		rec.Time.Format(time.RFC3339),
+		rec.Level.String(),
+		rec.LoggerName,
+		truncateMessage(rec.Message()),
+		rec.Filename,
+		line,
+		strings.TrimPrefix(string(appendFields(nil, rec.Fields)), " "),
+	})
+}
+
+// csvRow encodes the fields as a CSV row, without the terminating newline.
+func csvRow(fields []string) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write(fields)
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
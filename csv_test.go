@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCSVFormatter_Format(t *testing.T) {
+	f := &CSVFormatter{}
+	if h := f.Header(); h != "time,level,logger,message,file,line,fields" {
+		t.Errorf("unexpected header %q", h)
+	}
+
+	tests := []string{
+		"plain",
+		"with, comma",
+		`with "quotes"`,
+		"with\nnewline",
+	}
+	for _, message := range tests {
+		rec := &Record{
+			Format:     "%s\n",
+			Args:       []interface{}{message},
+			LoggerName: "csv",
+			Level:      WARNING,
+			Time:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			Filename:   "/src/app/main.go",
+			Line:       42,
+		}
+
+		out := f.Format(rec)
+		if strings.HasSuffix(out, "\n") {
+			t.Errorf("expected a row without newline got %q", out)
+		}
+
+		row, err := csv.NewReader(strings.NewReader(out)).Read()
+		if err != nil {
+			t.Errorf("invalid row %q: %s", out, err)
+			continue
+		}
+		expected := []string{"2024-01-02T03:04:05Z", "WARNING", "csv", message, "/src/app/main.go", "42", ""}
+		if strings.Join(row, "|") != strings.Join(expected, "|") {
+			t.Errorf("expected %q got %q", expected, row)
+		}
+	}
+
+	if out := f.Format(&Record{Format: `a "b", c`}); !strings.Contains(out, `"a ""b"", c"`) {
+		t.Errorf("expected the message to be quoted got %q", out)
+	}
+}
+
+func TestCSVFormatter_Fields(t *testing.T) {
+	rec := &Record{
+		Format: "login",
+		Level:  INFO,
+		Fields: Fields{"user": "bob smith", "attempt": 2},
+	}
+
+	row, err := csv.NewReader(strings.NewReader((&CSVFormatter{}).Format(rec))).Read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `attempt=2 user="bob smith"`; row[len(row)-1] != expected {
+		t.Errorf("expected fields column %q got %q", expected, row[len(row)-1])
+	}
+}
@@ -3,26 +3,24 @@ package logger
 import (
 	"fmt"
 	"os"
-	"strings"
 )
 
 type CustomFormatter struct{}
 
 func (f *CustomFormatter) Format(rec *Record) string {
-
-	paths := strings.Split(rec.Filename, string(os.PathSeparator))
-
-	filePath := strings.Join(paths[len(paths)-2:], string(os.PathSeparator))
-
-	return fmt.Sprintf("%-24s %-8s [%-15s][PID:%d][%s:%d] %s",
+	s := fmt.Sprintf("%-24s %-8s [%-15s][PID:%d][%s:%d] %s",
 		rec.Time.UTC().Format("2006-01-02T15:04:05.999Z"),
-		levelNames[rec.Level],
+		rec.Level,
 		rec.LoggerName,
 		rec.ProcessID,
-		filePath,
+		trimPath(nil, rec.Filename),
 		rec.Line,
-		fmt.Sprintf(rec.Format, rec.Args...),
+		truncateMessage(rec.Message()),
 	)
+	if len(rec.Fields) > 0 {
+		s = string(appendFields([]byte(s), rec.Fields))
+	}
+	return s
 }
 
 func NewCustom(name string, debug bool) Logger {
@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// DedupHandler collapses consecutive identical records, the way syslog does.
+//
+// The first record of a run is passed to the inner handler and its repeats
+// within the window are counted. When the window expires, or a different
+// record arrives first, a "last message repeated N times" record is sent.
+// Records are identical when their logger,
+// level and rendered message are equal.
+type DedupHandler struct {
+	inner  Handler
+	window time.Duration
+	now    func() time.Time
+
+	mu       sync.Mutex
+	last     Record    // last holds the first record of the current run
+	key      string    // key identifies the records of the current run
+	start    time.Time // start holds the time the current run started
+	repeated int
+	timer    *time.Timer // timer reports the repeats when the window expires
+}
+
+// NewDedupHandler creates a new handler collapsing records repeated within window.
+func NewDedupHandler(inner Handler, window time.Duration) *DedupHandler {
+	return &DedupHandler{
+		inner:  inner,
+		window: window,
+		now:    time.Now,
+	}
+}
+
+// SetLevel sets logger level for inner handler.
+func (h *DedupHandler) SetLevel(l level) {
+	h.inner.SetLevel(l)
+}
+
+// SetFormatter sets logger formatter for inner handler.
+func (h *DedupHandler) SetFormatter(f Formatter) {
+	h.inner.SetFormatter(f)
+}
+
+// Handle passes rec to the inner handler unless it repeats the previous record.
+func (h *DedupHandler) Handle(rec *Record) error {
+	key := rec.LoggerName + "\x00" + rec.Level.String() + "\x00" + rec.Message()
+	now := h.now()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if key == h.key && now.Sub(h.start) < h.window {
+		h.repeated++
+		if h.repeated == 1 {
+			start := h.start
+			h.timer = time.AfterFunc(start.Add(h.window).Sub(now), func() {
+				h.expire(key, start)
+			})
+		}
+		return nil
+	}
+
+	err := h.flush(now)
+	h.last = *rec
+	h.key = key
+	h.start = now
+	if herr := h.inner.Handle(rec); herr != nil {
+		err = herr
+	}
+	return err
+}
+
+// Flush reports the pending repeats and flushes the inner handler.
+func (h *DedupHandler) Flush() error {
+	h.mu.Lock()
+	err := h.flush(h.now())
+	h.mu.Unlock()
+
+	if ferr := h.inner.Flush(); ferr != nil {
+		err = ferr
+	}
+	return err
+}
+
+// Close reports the pending repeats and closes the inner handler.
+func (h *DedupHandler) Close() {
+	h.mu.Lock()
+	h.flush(h.now())
+	h.key = ""
+	h.mu.Unlock()
+
+	h.inner.Close()
+}
+
+// expire reports the repeats of the run identified by key and start when
+// its window expires, unless the run already ended.
+func (h *DedupHandler) expire(key string, start time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if key == h.key && start.Equal(h.start) {
+		h.flush(h.now())
+	}
+}
+
+// flush sends the repeat count of the current run, if any.
+func (h *DedupHandler) flush(now time.Time) error {
+	if h.repeated == 0 {
+		return nil
+	}
+	h.timer.Stop()
+
+	err := h.inner.Handle(&Record{
+		Format:      "last message repeated %d times\n",
+		Args:        []interface{}{h.repeated},
+		LoggerName:  h.last.LoggerName,
+		Level:       h.last.Level,
+		Time:        now,
+		ProcessID:   pid,
+		ProcessName: pname,
+	})
+	h.repeated = 0
+	return err
+}
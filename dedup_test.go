@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDedupHandler_Handle(t *testing.T) {
+	r := NewLogRecorder()
+	h := NewDedupHandler(r, time.Minute)
+
+	l := NewLogger("dedup")
+	l.SetHandler(h)
+
+	for i := 0; i < 4; i++ {
+		l.Info("disk %s full", "/var")
+	}
+	l.Info("disk %s full", "/tmp")
+	l.Info("disk %s full", "/var")
+	l.Error("disk %s full", "/var")
+	l.Error("disk %s full", "/var")
+	h.Close()
+
+	expected := []string{
+		"disk /var full",
+		"last message repeated 3 times",
+		"disk /tmp full",
+		"disk /var full",
+		"disk /var full",
+		"last message repeated 1 times",
+	}
+
+	recs := r.Records["dedup"]
+	if len(recs) != len(expected) {
+		t.Fatalf("expected %d records got %d", len(expected), len(recs))
+	}
+	for i, rec := range recs {
+		if msg := rec.Message(); msg != expected[i] {
+			t.Errorf("expected %q got %q", expected[i], msg)
+		}
+	}
+	if recs[5].Level != ERROR {
+		t.Errorf("expected the repeat count at the level of the repeated record")
+	}
+	if !r.Closed {
+		t.Errorf("inner handler is not closed")
+	}
+}
+
+func TestDedupHandler_Window(t *testing.T) {
+	r := NewLogRecorder()
+	h := NewDedupHandler(r, time.Second)
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	rec := &Record{LoggerName: "dedup", Format: "tick", Level: INFO}
+	h.Handle(rec)
+	h.Handle(rec)
+	now = now.Add(time.Second)
+	h.Handle(rec)
+
+	recs := r.Records["dedup"]
+	if len(recs) != 3 || recs[1].Message() != "last message repeated 1 times" || recs[2].Message() != "tick" {
+		t.Errorf("expected the run to end when the window expires got %v", recs)
+	}
+}
+
+func TestDedupHandler_Expire(t *testing.T) {
+	ch := make(chan *Record, 10)
+	h := NewDedupHandler(NewChannelHandler(ch, nil), 20*time.Millisecond)
+	defer h.Close()
+
+	rec := &Record{LoggerName: "dedup", Format: "tick", Level: INFO}
+	for i := 0; i < 3; i++ {
+		h.Handle(rec)
+	}
+	if got := (<-ch).Message(); got != "tick" {
+		t.Fatalf("unexpected record %q", got)
+	}
+
+	select {
+	case got := <-ch:
+		if msg := got.Message(); msg != "last message repeated 2 times" {
+			t.Errorf("unexpected repeat count %q", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the repeat count when the window expires without a new record")
+	}
+}
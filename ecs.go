@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ECSVersion is the version of the Elastic Common Schema of ECSFormatter.
+const ECSVersion = "1.12.0"
+
+// ecsLevels are the ECS level names by syslog severity.
+var ecsLevels = [...]string{2: "critical", 3: "error", 4: "warning", 5: "notice", 6: "info", 7: "debug"}
+
+// ECSFormatter formats records as single line JSON objects with the field
+// names of the Elastic Common Schema, for ingestion into Elasticsearch
+// without mapping. The structured fields of the record are added as the
+// "fields" object.
+type ECSFormatter struct{}
+
+// ecsRecord is the ECS representation of a record.
+type ecsRecord struct {
+	Timestamp    string `json:"@timestamp"`
+	Level        string `json:"log.level"`
+	Message      string `json:"message"`
+	ECSVersion   string `json:"ecs.version"`
+	Logger       string `json:"log.logger"`
+	File         string `json:"log.origin.file.name,omitempty"`
+	Line         int    `json:"log.origin.file.line,omitempty"`
+	Function     string `json:"log.origin.function,omitempty"`
+	PID          int    `json:"process.pid"`
+	ProcessName  string `json:"process.name,omitempty"`
+	ThreadID     uint64 `json:"process.thread.id,omitempty"`
+	Hostname     string `json:"host.hostname,omitempty"`
+	ErrorMessage string `json:"error.message,omitempty"`
+	ErrorStack   string `json:"error.stack_trace,omitempty"`
+
+	Fields map[string]json.RawMessage `json:"fields,omitempty"`
+}
+
+func (f *ECSFormatter) Format(rec *Record) string {
+	r := ecsRecord{
+		Timestamp:   rec.Time.Format(time.RFC3339Nano),
+		Level:       ecsLevels[severity(rec.Level)],
+		Message:     truncateMessage(rec.Message()),
+		ECSVersion:  ECSVersion,
+		Logger:      rec.LoggerName,
+		File:        rec.Filename,
+		Line:        rec.Line,
+		Function:    rec.Function,
+		PID:         rec.ProcessID,
+		ProcessName: rec.ProcessName,
+		ThreadID:    rec.GoroutineID,
+		Hostname:    rec.Hostname,
+		ErrorStack:  rec.Stack,
+		Fields:      jsonFields(rec.Fields),
+	}
+	if err := recordError(rec); err != nil {
+		r.ErrorMessage = err.Error()
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestECSFormatter_Format(t *testing.T) {
+	now := time.Date(2021, 3, 4, 5, 6, 7, 8000000, time.UTC)
+	rec := &Record{
+		Format:     "request failed: %v\n",
+		Args:       []interface{}{errors.New("timeout")},
+		LoggerName: "ecs",
+		Level:      ERROR,
+		Time:       now,
+		Filename:   "/src/app/main.go",
+		Line:       12,
+		ProcessID:  34,
+	}
+
+	out := (&ECSFormatter{}).Format(rec)
+	if strings.Contains(out, "\n") {
+		t.Errorf("expected a single line got %q", out)
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &m); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"@timestamp":           "2021-03-04T05:06:07.008Z",
+		"log.level":            "error",
+		"log.logger":           "ecs",
+		"message":              "request failed: timeout",
+		"ecs.version":          ECSVersion,
+		"process.pid":          float64(34),
+		"log.origin.file.name": "/src/app/main.go",
+		"log.origin.file.line": float64(12),
+		"error.message":        "timeout",
+	}
+	for k, v := range expected {
+		if m[k] != v {
+			t.Errorf("expected %s to be %v got %v", k, v, m[k])
+		}
+	}
+	if len(m) != len(expected) {
+		t.Errorf("expected %d keys got %v", len(expected), m)
+	}
+}
+
+func TestECSFormatter_Levels(t *testing.T) {
+	expected := map[level]string{
+		CRITICAL: "critical",
+		ERROR:    "error",
+		WARNING:  "warning",
+		NOTICE:   "notice",
+		INFO:     "info",
+		DEBUG:    "debug",
+	}
+	for l, name := range expected {
+		var m struct {
+			Level string `json:"log.level"`
+		}
+		if err := json.Unmarshal([]byte((&ECSFormatter{}).Format(&Record{Level: l})), &m); err != nil {
+			t.Fatal(err)
+		}
+		if m.Level != name {
+			t.Errorf("expected %s to map to %q got %q", l, name, m.Level)
+		}
+	}
+}
+
+func TestECSFormatter_Fields(t *testing.T) {
+	rec := &Record{Format: "login\n", Level: INFO, Fields: Fields{"user": "bob", "attempt": 2}}
+
+	var m struct {
+		Fields map[string]interface{} `json:"fields"`
+	}
+	out := (&ECSFormatter{}).Format(rec)
+	if err := json.Unmarshal([]byte(out), &m); err != nil {
+		t.Fatalf("invalid JSON %q: %s", out, err)
+	}
+	if m.Fields["user"] != "bob" || m.Fields["attempt"] != float64(2) {
+		t.Errorf("unexpected fields %v", m.Fields)
+	}
+}
@@ -0,0 +1,24 @@
+package logger
+
+import "errors"
+
+// recordError returns the first error among the arguments of the record,
+// nil if there is none.
+func recordError(rec *Record) error {
+	for _, arg := range rec.Args {
+		if err, ok := arg.(error); ok && err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errorChain returns the messages of the errors wrapped by err with %w,
+// outermost first. err itself is not included.
+func errorChain(err error) []string {
+	var chain []string
+	for err = errors.Unwrap(err); err != nil; err = errors.Unwrap(err) {
+		chain = append(chain, err.Error())
+	}
+	return chain
+}
@@ -0,0 +1,181 @@
+//go:build windows
+// +build windows
+
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32 = syscall.NewLazyDLL("advapi32.dll")
+
+	procRegCreateKeyExW       = advapi32.NewProc("RegCreateKeyExW")
+	procRegSetValueExW        = advapi32.NewProc("RegSetValueExW")
+	procRegisterEventSourceW  = advapi32.NewProc("RegisterEventSourceW")
+	procDeregisterEventSource = advapi32.NewProc("DeregisterEventSource")
+	procReportEventW          = advapi32.NewProc("ReportEventW")
+)
+
+// Event types of ReportEvent.
+const (
+	eventlogErrorType       = 0x0001
+	eventlogWarningType     = 0x0002
+	eventlogInformationType = 0x0004
+)
+
+// eventLogKey is the registry key of the sources of the Application log.
+const eventLogKey = `SYSTEM\CurrentControlSet\Services\EventLog\Application\`
+
+// eventMessageFile is the message file of the registered sources. Its
+// messages print the inserted string as is for the event IDs 1 to 1000.
+const eventMessageFile = `%SystemRoot%\System32\EventCreate.exe`
+
+// eventID is the ID of the events reported by EventLogHandler.
+const eventID = 1
+
+// EventLogHandler writes the logger output to the Application log of the
+// Windows Event Log.
+type EventLogHandler struct {
+	*BaseHandler
+	mu     sync.Mutex
+	handle syscall.Handle
+}
+
+// NewEventLogHandler creates a new handler reporting events from the given
+// source, registering the source first if needed. Registering a source
+// requires administrator rights, so services usually register it when they
+// are installed.
+func NewEventLogHandler(source string) (*EventLogHandler, error) {
+	if err := registerEventSource(source); err != nil {
+		return nil, fmt.Errorf("EventLogHandler can not register source %s: %s", source, err)
+	}
+
+	name, err := syscall.UTF16PtrFromString(source)
+	if err != nil {
+		return nil, err
+	}
+	r, _, err := procRegisterEventSourceW.Call(0, uintptr(unsafe.Pointer(name)))
+	if r == 0 {
+		return nil, fmt.Errorf("EventLogHandler can not open source %s: %s", source, err)
+	}
+
+	return &EventLogHandler{
+		BaseHandler: NewBaseHandler(),
+		handle:      syscall.Handle(r),
+	}, nil
+}
+
+// registerEventSource adds the source to the registry unless it exists.
+func registerEventSource(source string) error {
+	path, err := syscall.UTF16PtrFromString(eventLogKey + source)
+	if err != nil {
+		return err
+	}
+
+	var key syscall.Handle
+	if syscall.RegOpenKeyEx(syscall.HKEY_LOCAL_MACHINE, path, 0, syscall.KEY_READ, &key) == nil {
+		return syscall.RegCloseKey(key)
+	}
+
+	var disposition uint32
+	r, _, _ := procRegCreateKeyExW.Call(
+		uintptr(syscall.HKEY_LOCAL_MACHINE),
+		uintptr(unsafe.Pointer(path)),
+		0, 0, 0,
+		uintptr(syscall.KEY_SET_VALUE),
+		0,
+		uintptr(unsafe.Pointer(&key)),
+		uintptr(unsafe.Pointer(&disposition)),
+	)
+	if r != 0 {
+		return syscall.Errno(r)
+	}
+	defer syscall.RegCloseKey(key)
+
+	file, err := syscall.UTF16FromString(eventMessageFile)
+	if err != nil {
+		return err
+	}
+	if err := setRegValue(key, "EventMessageFile", syscall.REG_EXPAND_SZ,
+		unsafe.Pointer(&file[0]), len(file)*2); err != nil {
+		return err
+	}
+	types := uint32(eventlogErrorType | eventlogWarningType | eventlogInformationType)
+	return setRegValue(key, "TypesSupported", syscall.REG_DWORD, unsafe.Pointer(&types), 4)
+}
+
+// setRegValue sets the named value of the registry key.
+func setRegValue(key syscall.Handle, name string, typ uint32, data unsafe.Pointer, size int) error {
+	n, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	r, _, _ := procRegSetValueExW.Call(uintptr(key), uintptr(unsafe.Pointer(n)), 0,
+		uintptr(typ), uintptr(data), uintptr(size))
+	if r != 0 {
+		return syscall.Errno(r)
+	}
+	return nil
+}
+
+// eventType maps a level to the event type through its syslog severity, so
+// that custom levels get the type of the nearest standard level.
+func eventType(l level) uint16 {
+	switch severity(l) {
+	case 2, 3:
+		return eventlogErrorType
+	case 4:
+		return eventlogWarningType
+	default:
+		return eventlogInformationType
+	}
+}
+
+func (h *EventLogHandler) Handle(rec *Record) error {
+	message := h.BaseHandler.FilterAndFormat(rec)
+	if message == "" {
+		return nil
+	}
+
+	msg, err := syscall.UTF16PtrFromString(strings.TrimSuffix(message, "\n"))
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.handle == 0 {
+		return errors.New("EventLogHandler is closed")
+	}
+	r, _, err := procReportEventW.Call(
+		uintptr(h.handle),
+		uintptr(eventType(rec.Level)),
+		0,
+		eventID,
+		0,
+		1,
+		0,
+		uintptr(unsafe.Pointer(&msg)),
+		0,
+	)
+	if r == 0 {
+		return fmt.Errorf("EventLogHandler can not report event: %s", err)
+	}
+	return nil
+}
+
+// Close closes the handle of the event source.
+func (h *EventLogHandler) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.handle != 0 {
+		procDeregisterEventSource.Call(uintptr(h.handle))
+		h.handle = 0
+	}
+}
@@ -0,0 +1,59 @@
+//go:build windows
+// +build windows
+
+package logger
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventType(t *testing.T) {
+	expected := map[level]uint16{
+		CRITICAL: eventlogErrorType,
+		ERROR:    eventlogErrorType,
+		WARNING:  eventlogWarningType,
+		NOTICE:   eventlogInformationType,
+		INFO:     eventlogInformationType,
+		DEBUG:    eventlogInformationType,
+
+		CRITICAL - 1: eventlogErrorType,
+		DEBUG + 1:    eventlogInformationType,
+	}
+	for l, typ := range expected {
+		if got := eventType(l); got != typ {
+			t.Errorf("%s: expected event type %d got %d", l, typ, got)
+		}
+	}
+}
+
+func TestEventLogHandler_Handle(t *testing.T) {
+	const source = "github.com-ducksoso-logger-test"
+
+	h, err := NewEventLogHandler(source)
+	if err != nil {
+		t.Skipf("can not open event log, registering requires administrator rights: %s", err)
+	}
+	defer h.Close()
+	h.SetFormatter(&messageFormatter{})
+
+	message := fmt.Sprintf("event log test %d", time.Now().UnixNano())
+	if err := h.Handle(&Record{Format: message + "\n", Level: WARNING}); err != nil {
+		t.Fatal(err)
+	}
+
+	query := fmt.Sprintf("*[System[Provider[@Name='%s']]]", source)
+	out, err := exec.Command("wevtutil", "qe", "Application", "/q:"+query, "/c:1", "/rd:true", "/f:text").CombinedOutput()
+	if err != nil {
+		t.Fatalf("can not query event log: %s: %s", err, out)
+	}
+	if !strings.Contains(string(out), message) {
+		t.Errorf("expected event %q in %q", message, out)
+	}
+	if !strings.Contains(string(out), "Warning") {
+		t.Errorf("expected warning level in %q", out)
+	}
+}
@@ -1,7 +1,7 @@
 package main
 
 import (
-	"github.com/ducksoso/logger"
+	"github.com/ducksoso/logger/v2"
 )
 
 func main() {
@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FastFormatter produces the same output as TextFormatter while avoiding
+// most intermediate allocations: the line is built in a reused buffer and
+// the time, line and PID are appended without fmt.
+type FastFormatter struct {
+	// ShowProcess adds the process name and PID to the output.
+	ShowProcess bool
+
+	// TimePrecision sets the fractional second digits of the time. Default
+	// is Seconds.
+	TimePrecision TimePrecision
+
+	// TimeLayout overrides the layout of the time, e.g. time.RFC3339Nano.
+	// TimePrecision is ignored when it is set.
+	TimeLayout string
+
+	// Multiline selects how the newlines inside a record are output.
+	// Default is MultilineKeep.
+	Multiline MultilineMode
+
+	// TrimPath shortens the caller file name. DefaultTrimPath is used when
+	// it is nil.
+	TrimPath func(string) string
+}
+
+// bufferPool recycles the buffers of FastFormatter.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 256)
+		return &b
+	},
+}
+
+func (f *FastFormatter) Format(rec *Record) string {
+	bp := bufferPool.Get().(*[]byte)
+	b := (*bp)[:0]
+
+	if f.TimeLayout != "" {
+		b = rec.Time.AppendFormat(b, f.TimeLayout)
+	} else {
+		b = appendTime(b, rec, f.TimePrecision)
+	}
+	b = append(b, ' ')
+
+	name := rec.Level.String()
+	b = append(b, name...)
+	for i := utf8.RuneCountInString(name); i < 8; i++ { // padded by runes as with %-8s
+		b = append(b, ' ')
+	}
+
+	if f.ShowProcess {
+		b = append(b, '[')
+		b = append(b, rec.ProcessName...)
+		b = append(b, ':')
+		b = strconv.AppendInt(b, int64(rec.ProcessID), 10)
+		b = append(b, ']')
+	}
+	if rec.GoroutineID != 0 {
+		b = append(b, "[goroutine:"...)
+		b = strconv.AppendUint(b, rec.GoroutineID, 10)
+		b = append(b, ']')
+	}
+
+	b = append(b, '[')
+	b = append(b, trimPath(f.TrimPath, rec.Filename)...)
+	b = append(b, ':')
+	b = strconv.AppendInt(b, int64(rec.Line), 10)
+	b = append(b, "] "...)
+
+	if len(rec.Args) == 0 && !strings.Contains(rec.Format, "%") {
+		b = append(b, truncateMessage(rec.Format)...)
+	} else {
+		b = append(b, truncateMessage(interpolate(rec.Format, rec.Args))...)
+	}
+	b = trimNewlines(b)
+	if len(rec.Fields) > 0 {
+		b = appendFields(b, rec.Fields)
+	}
+	if rec.Stack != "" {
+		b = append(b, '\n')
+		b = append(b, rec.Stack...)
+		b = trimNewlines(b)
+	}
+
+	s := string(b)
+	*bp = b
+	bufferPool.Put(bp)
+	return f.Multiline.apply(s)
+}
+
+// trimNewlines returns b without its trailing newlines, as line does.
+func trimNewlines(b []byte) []byte {
+	for len(b) > 0 && b[len(b)-1] == '\n' {
+		b = b[:len(b)-1]
+	}
+	return b
+}
+
+// appendTime appends the time of the record in the layout of TextFormatter
+// with the given precision.
+func appendTime(b []byte, rec *Record, p TimePrecision) []byte {
+	year, month, day := rec.Time.Date()
+	if year < 1000 || year > 9999 {
+		return rec.Time.AppendFormat(b, p.layout())
+	}
+	hour, min, sec := rec.Time.Clock()
+
+	b = appendInt(b, year, 4)
+	b = append(b, '-')
+	b = appendInt(b, int(month), 2)
+	b = append(b, '-')
+	b = appendInt(b, day, 2)
+	b = append(b, ' ')
+	b = appendInt(b, hour, 2)
+	b = append(b, ':')
+	b = appendInt(b, min, 2)
+	b = append(b, ':')
+	b = appendInt(b, sec, 2)
+
+	if p <= Seconds {
+		return b
+	}
+	if p > Nanoseconds {
+		p = Nanoseconds
+	}
+	frac := rec.Time.Nanosecond()
+	for i := p; i < Nanoseconds; i++ {
+		frac /= 10
+	}
+	b = append(b, '.')
+	return appendInt(b, frac, int(p))
+}
+
+// appendInt appends the non-negative n zero padded to width digits.
+func appendInt(b []byte, n, width int) []byte {
+	var digits [20]byte
+	i := len(digits)
+	for n >= 10 || width > 1 {
+		i--
+		digits[i] = byte('0' + n%10)
+		n /= 10
+		width--
+	}
+	i--
+	digits[i] = byte('0' + n)
+	return append(b, digits[i:]...)
+}
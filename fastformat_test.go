@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFastFormatter_Format(t *testing.T) {
+	records := []*Record{
+		{
+			Format:   "plain message\n",
+			Level:    INFO,
+			Time:     time.Date(2021, 3, 4, 5, 6, 7, 8, time.Local),
+			Filename: "/src/app/main.go",
+			Line:     12,
+		},
+		{
+			Format:      "hello %s %d%%\n",
+			Args:        []interface{}{"world", 100},
+			Level:       CRITICAL,
+			Time:        time.Date(1999, 12, 31, 23, 59, 59, 0, time.UTC),
+			Filename:    "main.go",
+			Line:        1,
+			ProcessID:   1234,
+			ProcessName: "app",
+		},
+		{
+			Format: "no caller",
+			Level:  DEBUG,
+		},
+		{
+			Format:   "trailing newlines\n\n\n",
+			Level:    ERROR,
+			Filename: "main.go",
+			Line:     3,
+			Stack:    "goroutine 1 [running]:\nmain.main()\n\n",
+		},
+		{
+			Format:   "only stack newlines\n",
+			Level:    ERROR,
+			Filename: "main.go",
+			Line:     4,
+			Stack:    "goroutine 1 [running]:\n",
+		},
+	}
+
+	for _, showProcess := range []bool{false, true} {
+		text := &TextFormatter{ShowProcess: showProcess}
+		fast := &FastFormatter{ShowProcess: showProcess}
+		for _, rec := range records {
+			if expected, out := text.Format(rec), fast.Format(rec); out != expected {
+				t.Errorf("expected %q got %q", expected, out)
+			}
+		}
+	}
+}
+
+func TestFastFormatter_NonASCIILevel(t *testing.T) {
+	const TRACE = DEBUG + 1
+	RegisterLevel(TRACE, "TRAÇE", CYAN)
+	defer func() {
+		levelsMu.Lock()
+		delete(levelNames, TRACE)
+		delete(levelColors, TRACE)
+		levelsMu.Unlock()
+	}()
+
+	rec := &Record{Format: "message", Level: TRACE, Filename: "b/c.go", Line: 3}
+	if expected, out := (&TextFormatter{}).Format(rec), (&FastFormatter{}).Format(rec); out != expected {
+		t.Errorf("expected %q got %q", expected, out)
+	}
+}
+
+func benchmarkFormatter(b *testing.B, f Formatter) {
+	rec := &Record{
+		Format:      "request %s took %d ms\n",
+		Args:        []interface{}{"/index", 42},
+		Level:       INFO,
+		Time:        time.Now(),
+		Filename:    "/src/app/main.go",
+		Line:        12,
+		ProcessID:   1234,
+		ProcessName: "app",
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		f.Format(rec)
+	}
+}
+
+func BenchmarkTextFormatter(b *testing.B) {
+	benchmarkFormatter(b, &TextFormatter{})
+}
+
+func BenchmarkFastFormatter(b *testing.B) {
+	benchmarkFormatter(b, &FastFormatter{})
+}
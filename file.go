@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// FileHandler is a handler implementation that appends the logger output to a file.
+type FileHandler struct {
+	*BaseHandler
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// NewFileHandler creates a new file handler appending to the file at path.
+// The file is created if it does not exist.
+func NewFileHandler(path string) (*FileHandler, error) {
+	f, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &FileHandler{
+		BaseHandler: NewBaseHandler(),
+		path:        path,
+		file:        f,
+	}, nil
+}
+
+// NewJSONLFileHandler creates a new file handler appending the records to the
+// file at path as JSON Lines, each carrying the JSONSchemaVersion.
+func NewJSONLFileHandler(path string) (*FileHandler, error) {
+	h, err := NewFileHandler(path)
+	if err != nil {
+		return nil, err
+	}
+	h.Formatter = &JSONFormatter{Schema: true}
+	return h, nil
+}
+
+// Handle writes the formatted record to the file.
+func (h *FileHandler) Handle(rec *Record) error {
+	message := h.BaseHandler.FilterAndFormat(rec)
+	if message == "" {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.file.WriteString(line(message))
+	return err
+}
+
+// Reopen closes the file and opens the configured path again, so the handler
+// writes to a new file after an external tool like logrotate moved the old
+// one. It is usually called on SIGHUP:
+//
+//	c := make(chan os.Signal, 1)
+//	signal.Notify(c, syscall.SIGHUP)
+//	go func() {
+//		for range c {
+//			h.Reopen()
+//		}
+//	}()
+//
+// The current file is kept if the path can not be opened.
+func (h *FileHandler) Reopen() error {
+	f, err := openLogFile(h.path)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	old := h.file
+	h.file = f
+	return old.Close()
+}
+
+// Close closes the underlying file.
+func (h *FileHandler) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.file.Close()
+}
+
+// openLogFile opens the file at path for appending log output.
+func openLogFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+}
+
+// line terminates the formatted message with exactly one newline. Formatters
+// return messages without a trailing newline, but the newlines ending the
+// message of the record itself are dropped here too.
+func line(message string) string {
+	return strings.TrimRight(message, "\n") + "\n"
+}
@@ -0,0 +1,162 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileHandler_Handle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	h, err := NewFileHandler(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.SetFormatter(&JSONFormatter{})
+
+	l := NewLogger("file")
+	l.SetHandler(h)
+	for i := 0; i < 3; i++ {
+		l.Info("record %d", i)
+	}
+	h.Close()
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines got %d: %q", len(lines), b)
+	}
+	for i, line := range lines {
+		if !strings.Contains(line, fmt.Sprintf(`"message":"record %d"`, i)) {
+			t.Errorf("unexpected line %q", line)
+		}
+	}
+}
+
+func TestFileHandler_OneNewlinePerRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	h, err := NewFileHandler(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := []string{"plain", "trailing space  ", "trailing newline\n", "trailing newlines\n\n", "tab\t\n"}
+	formatters := []Formatter{&TextFormatter{}, &FastFormatter{}, &LogfmtFormatter{}, &JSONFormatter{}}
+	l := NewLogger("newline")
+	l.SetHandler(h)
+	for _, f := range formatters {
+		h.SetFormatter(f)
+		for _, msg := range messages {
+			l.Info(msg)
+		}
+	}
+	h.Close()
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := len(messages) * len(formatters)
+	if n := strings.Count(string(b), "\n"); n != expected {
+		t.Errorf("expected %d newlines got %d in %q", expected, n, b)
+	}
+	if strings.Contains(string(b), "\n\n") {
+		t.Errorf("unexpected empty line in %q", b)
+	}
+}
+
+func TestNewFileHandler_Error(t *testing.T) {
+	if _, err := NewFileHandler(filepath.Join(t.TempDir(), "missing", "app.log")); err == nil {
+		t.Errorf("expected an error for a missing directory")
+	}
+}
+
+func TestFileHandler_HandleError(t *testing.T) {
+	h, err := NewFileHandler(filepath.Join(t.TempDir(), "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Close()
+
+	var hooked error
+	l := NewLogger("file")
+	l.SetHandler(h)
+	l.SetErrorHook(func(err error) { hooked = err })
+	l.Info("after close")
+
+	if hooked == nil {
+		t.Errorf("expected the write error to reach the hook")
+	}
+}
+
+func TestFileHandler_Reopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	h, err := NewFileHandler(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.SetFormatter(&messageFormatter{})
+
+	h.Handle(&Record{Format: "before\n"})
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	h.Handle(&Record{Format: "rotated\n"})
+	if err := h.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	h.Handle(&Record{Format: "after\n"})
+
+	if b, _ := ioutil.ReadFile(path + ".1"); string(b) != "before\nrotated\n" {
+		t.Errorf("unexpected rotated file %q", b)
+	}
+	if b, _ := ioutil.ReadFile(path); string(b) != "after\n" {
+		t.Errorf("unexpected reopened file %q", b)
+	}
+}
+
+func TestJSONLFileHandler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.jsonl")
+
+	h, err := NewJSONLFileHandler(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLogger("jsonl")
+	l.SetHandler(h)
+	l.Info("first")
+	l.Warning("second, with \"quotes\"\nand a newline")
+	h.Close()
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines got %q", b)
+	}
+	for _, line := range lines {
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &m); err != nil {
+			t.Errorf("invalid JSON line %q: %s", line, err)
+			continue
+		}
+		if m["schema"] != float64(JSONSchemaVersion) {
+			t.Errorf("expected schema %d got %v", JSONSchemaVersion, m["schema"])
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"path"
+	"regexp"
+	"strings"
+)
+
+// FilterHandler passes to the inner handler only the records accepted by
+// a predicate.
+type FilterHandler struct {
+	inner  Handler
+	accept func(*Record) bool
+}
+
+// NewFilterHandler creates a new filter handler passing the records for
+// which accept returns true to inner and dropping the others.
+func NewFilterHandler(inner Handler, accept func(*Record) bool) *FilterHandler {
+	return &FilterHandler{inner: inner, accept: accept}
+}
+
+// SetLevel sets logger level for inner handler.
+func (h *FilterHandler) SetLevel(l level) {
+	h.inner.SetLevel(l)
+}
+
+// SetFormatter sets logger formatter for inner handler.
+func (h *FilterHandler) SetFormatter(f Formatter) {
+	h.inner.SetFormatter(f)
+}
+
+// Handle passes the record to the inner handler if it is accepted.
+func (h *FilterHandler) Handle(rec *Record) error {
+	if !h.accept(rec) {
+		return nil
+	}
+	return h.inner.Handle(rec)
+}
+
+// Flush flushes the inner handler.
+func (h *FilterHandler) Flush() error {
+	return h.inner.Flush()
+}
+
+// Close closes the inner handler.
+func (h *FilterHandler) Close() {
+	h.inner.Close()
+}
+
+// LevelRange returns a predicate accepting the records with levels between
+// from and to, inclusive.
+func LevelRange(from, to level) func(*Record) bool {
+	if from > to {
+		from, to = to, from
+	}
+	return func(rec *Record) bool {
+		return rec.Level >= from && rec.Level <= to
+	}
+}
+
+// LoggerNameGlob returns a predicate accepting the records of the loggers
+// whose name matches the pattern, in the syntax of path.Match. A malformed
+// pattern matches no name.
+func LoggerNameGlob(pattern string) func(*Record) bool {
+	return func(rec *Record) bool {
+		matched, _ := path.Match(pattern, rec.LoggerName)
+		return matched
+	}
+}
+
+// MessageContains returns a predicate accepting the records whose message
+// contains substr.
+func MessageContains(substr string) func(*Record) bool {
+	return func(rec *Record) bool {
+		return strings.Contains(rec.Message(), substr)
+	}
+}
+
+// MessageMatches returns a predicate accepting the records whose message
+// matches re.
+func MessageMatches(re *regexp.Regexp) func(*Record) bool {
+	return func(rec *Record) bool {
+		return re.MatchString(rec.Message())
+	}
+}
+
+// Not returns a predicate accepting the records rejected by accept, e.g. to
+// drop the records of a logger.
+func Not(accept func(*Record) bool) func(*Record) bool {
+	return func(rec *Record) bool {
+		return !accept(rec)
+	}
+}
@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestFilterHandler_Handle(t *testing.T) {
+	r := NewLogRecorder()
+	h := NewFilterHandler(r, Not(LoggerNameGlob("noisy")))
+
+	for _, name := range []string{"noisy", "quiet"} {
+		l := NewLogger(name)
+		l.SetHandler(h)
+		l.Info("message")
+	}
+
+	if n := len(r.Records["noisy"]); n != 0 {
+		t.Errorf("expected noisy records to be dropped got %d", n)
+	}
+	if n := len(r.Records["quiet"]); n != 1 {
+		t.Errorf("expected 1 quiet record got %d", n)
+	}
+
+	h.SetLevel(ERROR)
+	h.Close()
+	if r.Level != ERROR || !r.Closed {
+		t.Errorf("level and close are not propagated")
+	}
+}
+
+func TestFilterPredicates(t *testing.T) {
+	tests := []struct {
+		name     string
+		accept   func(*Record) bool
+		rec      *Record
+		expected bool
+	}{
+		{"level in range", LevelRange(WARNING, ERROR), &Record{Level: ERROR}, true},
+		{"level in reversed range", LevelRange(ERROR, WARNING), &Record{Level: WARNING}, true},
+		{"level above range", LevelRange(WARNING, ERROR), &Record{Level: CRITICAL}, false},
+		{"level below range", LevelRange(WARNING, ERROR), &Record{Level: INFO}, false},
+		{"glob match", LoggerNameGlob("db.*"), &Record{LoggerName: "db.pool"}, true},
+		{"glob no match", LoggerNameGlob("db.*"), &Record{LoggerName: "web"}, false},
+		{"glob exact", LoggerNameGlob("web"), &Record{LoggerName: "web"}, true},
+		{"glob malformed", LoggerNameGlob("[db"), &Record{LoggerName: "[db"}, false},
+		{"contains", MessageContains("timeout"), &Record{Format: "request %s\n", Args: []interface{}{"timeout"}}, true},
+		{"not contains", MessageContains("timeout"), &Record{Format: "request ok\n"}, false},
+		{"matches", MessageMatches(regexp.MustCompile(`^user \d+$`)), &Record{Format: "user %d\n", Args: []interface{}{42}}, true},
+		{"not matches", MessageMatches(regexp.MustCompile(`^user \d+$`)), &Record{Format: "user bob\n"}, false},
+		{"not", Not(LevelRange(DEBUG, DEBUG)), &Record{Level: DEBUG}, false},
+	}
+
+	for _, test := range tests {
+		if got := test.accept(test.rec); got != test.expected {
+			t.Errorf("%s: expected %v got %v", test.name, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package logger
+
+import (
+	"net"
+	"strings"
+	"time"
+)
+
+// FluentHandler sends the logger output to Fluentd or Fluent Bit with the
+// Forward protocol over TCP.
+//
+// Events are tagged with the logger name, prefixed with TagPrefix when it is
+// set, and carry the time, level, message and structured fields. They are
+// collected until BatchSize records are pending or the flush interval
+// elapses, and are then sent with a Forward mode frame per tag. Failed sends
+// are retried on a new connection with exponential backoff.
+type FluentHandler struct {
+	*BaseHandler
+
+	// TagPrefix is prepended to the logger name, separated by a dot, to
+	// build the event tags.
+	TagPrefix string
+
+	// BatchSize is the number of records that triggers a send.
+	BatchSize int
+
+	// MaxRetries is the number of times a failed send is retried.
+	MaxRetries int
+
+	// RetryBackoff is the wait before the first retry, doubled on each retry.
+	RetryBackoff time.Duration
+
+	// DialTimeout is the timeout of the connections to the server.
+	DialTimeout time.Duration
+
+	addr    string
+	conn    net.Conn // conn is guarded by the flushes of batcher
+	batcher *batcher
+}
+
+// NewFluentHandler creates a new Fluent handler sending batches of up to
+// batchSize records to the forward input at addr, e.g. "localhost:24224", at
+// least every flushInterval. A non-positive flushInterval disables the
+// periodic sends. The connection is opened on the first send.
+func NewFluentHandler(addr string, batchSize int, flushInterval time.Duration) *FluentHandler {
+	h := &FluentHandler{
+		BaseHandler:  NewBaseHandler(),
+		BatchSize:    batchSize,
+		MaxRetries:   3,
+		RetryBackoff: 100 * time.Millisecond,
+		DialTimeout:  5 * time.Second,
+		addr:         addr,
+	}
+	h.batcher = newBatcher("FluentHandler", flushInterval, h.split, func(batch []batchEntry) error {
+		return h.send(h.encode(batch))
+	})
+	return h
+}
+
+// Handle adds the record to the pending batch. Sending the batch happens in
+// the background, its failures are reported on stderr or by Flush.
+func (h *FluentHandler) Handle(rec *Record) error {
+	message := h.BaseHandler.FilterAndFormat(rec)
+	if message == "" {
+		return nil
+	}
+	return h.batcher.add(batchEntry{rec: *rec, message: strings.TrimSuffix(message, "\n")})
+}
+
+// Flush sends the pending records and returns the error of the last failed send.
+func (h *FluentHandler) Flush() error {
+	return h.batcher.flush(true)
+}
+
+// Close sends the pending batch, stops the handler and closes the
+// connection. Closing it again has no effect.
+func (h *FluentHandler) Close() {
+	h.batcher.close()
+
+	h.batcher.flushMu.Lock()
+	defer h.batcher.flushMu.Unlock()
+	if h.conn != nil {
+		h.conn.Close()
+		h.conn = nil
+	}
+}
+
+// split returns the next batch of up to BatchSize records.
+func (h *FluentHandler) split(pending []batchEntry) (int, bool) {
+	return splitCount(pending, h.BatchSize)
+}
+
+// tag returns the tag of the events of the logger.
+func (h *FluentHandler) tag(name string) string {
+	if h.TagPrefix == "" {
+		return name
+	}
+	if name == "" {
+		return h.TagPrefix
+	}
+	return h.TagPrefix + "." + name
+}
+
+// encode encodes the batch as a Forward mode frame per tag, in the order of
+// their first record.
+func (h *FluentHandler) encode(batch []batchEntry) []byte {
+	var tags []string
+	entries := make(map[string][]*batchEntry)
+	for i := range batch {
+		tag := h.tag(batch[i].rec.LoggerName)
+		if _, ok := entries[tag]; !ok {
+			tags = append(tags, tag)
+		}
+		entries[tag] = append(entries[tag], &batch[i])
+	}
+
+	var b []byte
+	for _, tag := range tags {
+		b = appendMsgpackArrayHeader(b, 2)
+		b = appendMsgpackString(b, tag)
+		b = appendMsgpackArrayHeader(b, len(entries[tag]))
+		for _, e := range entries[tag] {
+			b = appendMsgpackArrayHeader(b, 2)
+			b = appendMsgpackEventTime(b, e.rec.Time)
+			b = appendMsgpackMap(b, fluentRecord(e))
+		}
+	}
+	return b
+}
+
+// fluentRecord returns the event record of a pending record.
+func fluentRecord(e *batchEntry) map[string]interface{} {
+	rec := &e.rec
+	m := make(map[string]interface{}, len(rec.Fields)+5)
+	for k, v := range rec.Fields {
+		m[k] = v
+	}
+	m["message"] = e.message
+	m["level"] = rec.Level.String()
+	m["logger"] = rec.LoggerName
+	if rec.Filename != "" {
+		m["file"] = rec.Filename
+		m["line"] = rec.Line
+	}
+	return m
+}
+
+// send writes b to the server, reconnecting and retrying on failures.
+func (h *FluentHandler) send(b []byte) (err error) {
+	backoff := h.RetryBackoff
+	for i := 0; ; i++ {
+		if h.conn == nil {
+			h.conn, err = net.DialTimeout("tcp", h.addr, h.DialTimeout)
+		}
+		if err == nil {
+			if _, err = h.conn.Write(b); err == nil {
+				return nil
+			}
+			h.conn.Close()
+			h.conn = nil
+		}
+		if i >= h.MaxRetries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"io/ioutil"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// readFluentFrames accepts a connection on ln and decodes the frames sent
+// until the connection is closed.
+func readFluentFrames(t *testing.T, ln net.Listener) []interface{} {
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	b, err := ioutil.ReadAll(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var frames []interface{}
+	for len(b) > 0 {
+		var frame interface{}
+		if frame, b, err = decodeMsgpack(b); err != nil {
+			t.Fatal(err)
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+func TestFluentHandler_Handle(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	h := NewFluentHandler(ln.Addr().String(), 10, time.Hour)
+	h.TagPrefix = "app"
+	h.SetFormatter(&messageFormatter{})
+
+	tm := time.Unix(1500000000, 500000000)
+	h.Handle(&Record{Format: "first\n", LoggerName: "db", Level: ERROR, Time: tm, Fields: Fields{"user": "bob"}})
+	h.Handle(&Record{Format: "second %d\n", Args: []interface{}{2}, LoggerName: "web", Level: INFO, Time: tm})
+	h.Handle(&Record{Format: "third\n", LoggerName: "db", Level: INFO, Time: tm})
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	h.Close()
+
+	frames := readFluentFrames(t, ln)
+	if len(frames) != 2 {
+		t.Fatalf("expected a frame per tag got %d", len(frames))
+	}
+
+	expected := []struct {
+		tag      string
+		messages []string
+	}{
+		{"app.db", []string{"first", "third"}},
+		{"app.web", []string{"second 2"}},
+	}
+	for i, frame := range frames {
+		f := frame.([]interface{})
+		if tag := f[0]; tag != expected[i].tag {
+			t.Errorf("expected tag %q got %q", expected[i].tag, tag)
+		}
+		entries := f[1].([]interface{})
+		if len(entries) != len(expected[i].messages) {
+			t.Fatalf("expected %d entries got %d", len(expected[i].messages), len(entries))
+		}
+		for j, entry := range entries {
+			e := entry.([]interface{})
+			if et := e[0].(time.Time); !et.Equal(tm) {
+				t.Errorf("expected time %s got %s", tm, et)
+			}
+			if msg := e[1].(map[string]interface{})["message"]; msg != expected[i].messages[j] {
+				t.Errorf("expected message %q got %q", expected[i].messages[j], msg)
+			}
+		}
+	}
+
+	record := frames[0].([]interface{})[1].([]interface{})[0].([]interface{})[1]
+	expectedRecord := map[string]interface{}{
+		"message": "first",
+		"level":   "ERROR",
+		"logger":  "db",
+		"user":    "bob",
+	}
+	if !reflect.DeepEqual(record, expectedRecord) {
+		t.Errorf("expected %v got %v", expectedRecord, record)
+	}
+}
+
+func TestFluentHandler_Retry(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	h := NewFluentHandler(addr, 10, time.Hour)
+	h.RetryBackoff = 50 * time.Millisecond
+	h.MaxRetries = 5
+	h.SetFormatter(&messageFormatter{})
+	h.Handle(&Record{Format: "retried\n", LoggerName: "retry", Level: INFO})
+
+	// Start the server after the first attempt failed.
+	lnCh := make(chan net.Listener, 1)
+	go func() {
+		time.Sleep(75 * time.Millisecond)
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Error(err)
+		}
+		lnCh <- ln
+	}()
+
+	if err := h.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	h.Close()
+
+	ln = <-lnCh
+	if ln == nil {
+		t.FailNow()
+	}
+	defer ln.Close()
+
+	frames := readFluentFrames(t, ln)
+	if len(frames) != 1 || frames[0].([]interface{})[0] != "retry" {
+		t.Errorf("expected a frame tagged retry got %v", frames)
+	}
+}
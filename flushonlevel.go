@@ -0,0 +1,80 @@
+package logger
+
+import "sync"
+
+// FlushOnLevelHandler buffers the records below a trigger level and passes
+// them to the inner handler only when a record at or above the trigger level
+// arrives, giving the context of failures without logging everything.
+//
+// The buffer holds the most recent records, older ones are dropped when it
+// is full. Buffered records never followed by a trigger are discarded.
+type FlushOnLevelHandler struct {
+	inner   Handler
+	trigger level
+
+	mu      sync.Mutex
+	size    int
+	records []Record
+}
+
+// NewFlushOnLevelHandler creates a new handler buffering up to size records
+// below trigger for inner.
+func NewFlushOnLevelHandler(inner Handler, trigger level, size int) *FlushOnLevelHandler {
+	return &FlushOnLevelHandler{
+		inner:   inner,
+		trigger: trigger,
+		size:    size,
+	}
+}
+
+// SetLevel sets logger level for inner handler.
+func (h *FlushOnLevelHandler) SetLevel(l level) {
+	h.inner.SetLevel(l)
+}
+
+// SetFormatter sets logger formatter for inner handler.
+func (h *FlushOnLevelHandler) SetFormatter(f Formatter) {
+	h.inner.SetFormatter(f)
+}
+
+// Handle buffers a copy of rec if it is below the trigger level. Otherwise
+// it passes the buffered records followed by rec to the inner handler.
+func (h *FlushOnLevelHandler) Handle(rec *Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if rec.Level > h.trigger {
+		if h.size <= 0 {
+			return nil
+		}
+		if len(h.records) == h.size {
+			copy(h.records, h.records[1:])
+			h.records = h.records[:len(h.records)-1]
+		}
+		h.records = append(h.records, *rec)
+		return nil
+	}
+
+	var errs multiError
+	for i := range h.records {
+		errs.add(h.inner.Handle(&h.records[i]))
+	}
+	h.records = h.records[:0]
+	errs.add(h.inner.Handle(rec))
+	return errs.err()
+}
+
+// Flush flushes the inner handler. The buffered records are kept until a
+// trigger.
+func (h *FlushOnLevelHandler) Flush() error {
+	return h.inner.Flush()
+}
+
+// Close discards the buffered records and closes the inner handler.
+func (h *FlushOnLevelHandler) Close() {
+	h.mu.Lock()
+	h.records = nil
+	h.mu.Unlock()
+
+	h.inner.Close()
+}
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFlushOnLevelHandler(t *testing.T) {
+	r := NewLogRecorder()
+	h := NewFlushOnLevelHandler(r, ERROR, 3)
+
+	l := NewLogger("tail")
+	l.SetLevel(DEBUG)
+	l.SetHandler(h)
+	for i := 0; i < 4; i++ {
+		l.Info("record %d", i)
+	}
+	if n := len(r.Records["tail"]); n != 0 {
+		t.Fatalf("expected the records to be buffered got %d", n)
+	}
+
+	l.Error("failure")
+	l.Debug("after")
+
+	var msgs []string
+	for _, rec := range r.Records["tail"] {
+		msgs = append(msgs, rec.Message())
+	}
+	if s := fmt.Sprint(msgs); s != "[record 1 record 2 record 3 failure]" {
+		t.Errorf("expected the recent records followed by the trigger got %s", s)
+	}
+
+	l.Critical("again")
+	if recs := r.Records["tail"]; len(recs) != 6 || recs[4].Message() != "after" || recs[5].Message() != "again" {
+		t.Errorf("expected the buffer to restart after a trigger got %d records", len(recs))
+	}
+}
@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// gcpSeverities are the Cloud Logging severities by syslog severity.
+var gcpSeverities = [...]string{2: "CRITICAL", 3: "ERROR", 4: "WARNING", 5: "NOTICE", 6: "INFO", 7: "DEBUG"}
+
+// GCPFormatter formats records as single line JSON objects in the
+// structured logging format of Google Cloud Logging, which parses them
+// from the output of Cloud Run, GKE and the Ops Agent without configuration.
+//
+// The structured fields of the record are added as top level keys, which
+// Cloud Logging moves to the jsonPayload of the entry. Fields named like
+// the keys of the format are dropped. The trace_id and span_id fields set
+// by LogCtx are output as the trace and span of the entry instead.
+type GCPFormatter struct {
+	// ProjectID qualifies the trace IDs as projects/ProjectID/traces/ID,
+	// the form Cloud Logging needs to link entries to Cloud Trace. The
+	// IDs are output as is when it is empty.
+	ProjectID string
+}
+
+// gcpRecord is the Cloud Logging representation of a record.
+type gcpRecord struct {
+	Severity       string             `json:"severity"`
+	Message        string             `json:"message"`
+	Timestamp      string             `json:"timestamp"`
+	SourceLocation *gcpSourceLocation `json:"logging.googleapis.com/sourceLocation,omitempty"`
+	Labels         map[string]string  `json:"logging.googleapis.com/labels,omitempty"`
+	Trace          string             `json:"logging.googleapis.com/trace,omitempty"`
+	SpanID         string             `json:"logging.googleapis.com/spanId,omitempty"`
+}
+
+// gcpSourceLocation is the caller of a record. Cloud Logging encodes the
+// line as a string since it is an int64.
+type gcpSourceLocation struct {
+	File     string `json:"file"`
+	Line     string `json:"line"`
+	Function string `json:"function,omitempty"`
+}
+
+func (f *GCPFormatter) Format(rec *Record) string {
+	r := gcpRecord{
+		Severity:  gcpSeverities[severity(rec.Level)],
+		Message:   truncateMessage(rec.Message()),
+		Timestamp: rec.Time.Format(time.RFC3339Nano),
+	}
+	if rec.Stack != "" {
+		// Error Reporting picks up the stack when it follows the message.
+		r.Message += "\n" + rec.Stack
+	}
+	if rec.Filename != "" {
+		r.SourceLocation = &gcpSourceLocation{
+			File:     rec.Filename,
+			Line:     strconv.Itoa(rec.Line),
+			Function: rec.Function,
+		}
+	}
+	if rec.LoggerName != "" {
+		r.Labels = map[string]string{"logger": rec.LoggerName}
+	}
+
+	if traceID, ok := rec.Fields[traceIDField].(string); ok {
+		r.Trace = traceID
+		if f.ProjectID != "" {
+			r.Trace = "projects/" + f.ProjectID + "/traces/" + traceID
+		}
+	}
+	if spanID, ok := rec.Fields[spanIDField].(string); ok {
+		r.SpanID = spanID
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return ""
+	}
+	payload := jsonFields(rec.Fields)
+	if r.Trace != "" {
+		delete(payload, traceIDField)
+	}
+	if r.SpanID != "" {
+		delete(payload, spanIDField)
+	}
+	if len(payload) == 0 {
+		return string(b)
+	}
+
+	// The keys of the record replace the fields with the same names.
+	if err := json.Unmarshal(b, &payload); err != nil {
+		return ""
+	}
+	if b, err = json.Marshal(payload); err != nil {
+		return ""
+	}
+	return string(b)
+}
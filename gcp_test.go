@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGCPFormatter_Format(t *testing.T) {
+	now := time.Date(2021, 3, 4, 5, 6, 7, 8000000, time.UTC)
+	rec := &Record{
+		Format:     "hello %s\n",
+		Args:       []interface{}{"cloud"},
+		LoggerName: "gcp",
+		Level:      WARNING,
+		Time:       now,
+		Filename:   "/src/app/main.go",
+		Line:       12,
+		Function:   "main.handle",
+	}
+
+	out := (&GCPFormatter{}).Format(rec)
+	if strings.Contains(out, "\n") {
+		t.Errorf("expected a single line got %q", out)
+	}
+
+	var m struct {
+		Severity       string `json:"severity"`
+		Message        string `json:"message"`
+		Timestamp      string `json:"timestamp"`
+		SourceLocation struct {
+			File     string `json:"file"`
+			Line     string `json:"line"`
+			Function string `json:"function"`
+		} `json:"logging.googleapis.com/sourceLocation"`
+		Labels map[string]string `json:"logging.googleapis.com/labels"`
+	}
+	if err := json.Unmarshal([]byte(out), &m); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Severity != "WARNING" || m.Message != "hello cloud" || m.Timestamp != "2021-03-04T05:06:07.008Z" {
+		t.Errorf("unexpected severity, message or timestamp in %q", out)
+	}
+	loc := m.SourceLocation
+	if loc.File != "/src/app/main.go" || loc.Line != "12" || loc.Function != "main.handle" {
+		t.Errorf("unexpected source location %+v", loc)
+	}
+	if m.Labels["logger"] != "gcp" {
+		t.Errorf("expected logger label got %v", m.Labels)
+	}
+
+	out = (&GCPFormatter{}).Format(&Record{Format: "no caller\n", Level: INFO})
+	if strings.Contains(out, "sourceLocation") {
+		t.Errorf("expected no source location got %q", out)
+	}
+}
+
+func TestGCPFormatter_Severity(t *testing.T) {
+	expected := map[level]string{
+		CRITICAL: "CRITICAL",
+		ERROR:    "ERROR",
+		WARNING:  "WARNING",
+		NOTICE:   "NOTICE",
+		INFO:     "INFO",
+		DEBUG:    "DEBUG",
+	}
+	for l, name := range expected {
+		var m struct {
+			Severity string `json:"severity"`
+		}
+		if err := json.Unmarshal([]byte((&GCPFormatter{}).Format(&Record{Level: l})), &m); err != nil {
+			t.Fatal(err)
+		}
+		if m.Severity != name {
+			t.Errorf("expected %s to map to %q got %q", l, name, m.Severity)
+		}
+	}
+}
+
+func TestGCPFormatter_Fields(t *testing.T) {
+	rec := &Record{
+		Format: "login\n",
+		Level:  INFO,
+		Fields: Fields{"user": "bob", "attempt": 2, "severity": "spoofed"},
+	}
+
+	var m map[string]interface{}
+	out := (&GCPFormatter{}).Format(rec)
+	if err := json.Unmarshal([]byte(out), &m); err != nil {
+		t.Fatalf("invalid JSON %q: %s", out, err)
+	}
+	if m["user"] != "bob" || m["attempt"] != float64(2) {
+		t.Errorf("expected the fields as top level keys got %q", out)
+	}
+	if m["severity"] != "INFO" || m["message"] != "login" {
+		t.Errorf("expected the record keys to take precedence got %q", out)
+	}
+}
@@ -0,0 +1,159 @@
+package logger
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// GELF chunking parameters.
+const (
+	gelfChunkHeaderSize = 12
+	gelfMaxChunks       = 128
+
+	// DefaultGELFChunkSize is the default maximum size of a GELF datagram.
+	DefaultGELFChunkSize = 1420
+)
+
+// gelfChunkMagic starts every chunk of a chunked GELF message.
+var gelfChunkMagic = []byte{0x1e, 0x0f}
+
+// GELFHandler sends the logger output to Graylog as GELF 1.1 messages over UDP.
+// Messages larger than ChunkSize are split into chunks.
+type GELFHandler struct {
+	*BaseHandler
+
+	// ChunkSize is the maximum size of a datagram. Messages needing chunks
+	// are rejected unless it exceeds the 12 bytes of the chunk header.
+	ChunkSize int
+
+	mu       sync.Mutex
+	conn     net.Conn
+	hostname string
+}
+
+// NewGELFHandler creates a new GELF handler sending to the Graylog UDP input at addr.
+func NewGELFHandler(addr string) (*GELFHandler, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+
+	return &GELFHandler{
+		BaseHandler: NewBaseHandler(),
+		ChunkSize:   DefaultGELFChunkSize,
+		conn:        conn,
+		hostname:    hostname,
+	}, nil
+}
+
+func (h *GELFHandler) Handle(rec *Record) error {
+	message := h.BaseHandler.FilterAndFormat(rec)
+	if message == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(h.gelfMessage(rec, strings.TrimSuffix(message, "\n")))
+	if err != nil {
+		return fmt.Errorf("GELFHandler can not encode record: %s", err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if err := h.send(b); err != nil {
+		return fmt.Errorf("GELFHandler can not send record: %s", err)
+	}
+	return nil
+}
+
+// Close closes the connection.
+func (h *GELFHandler) Close() {
+	h.conn.Close()
+}
+
+// gelfMessage returns the GELF representation of the record.
+// Structured fields are added as additional fields prefixed with an underscore.
+// GELF only allows strings and numbers as their values: errors are written
+// as their message and other values in their fmt.Sprint form.
+func (h *GELFHandler) gelfMessage(rec *Record, message string) map[string]interface{} {
+	m := map[string]interface{}{
+		"version":       "1.1",
+		"host":          h.hostname,
+		"short_message": message,
+		"timestamp":     float64(rec.Time.UnixNano()) / 1e9,
+		"level":         severity(rec.Level),
+		"_logger":       rec.LoggerName,
+		"_file":         rec.Filename,
+		"_line":         rec.Line,
+		"_pid":          rec.ProcessID,
+	}
+	for k, v := range rec.Fields {
+		if k == "id" { // _id is reserved by GELF
+			continue
+		}
+		m["_"+k] = gelfValue(v)
+	}
+	return m
+}
+
+// gelfValue returns the value of an additional field, a string or a number.
+func gelfValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	case error:
+		return v.Error()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// send writes b as a single datagram, or in chunks if it does not fit.
+func (h *GELFHandler) send(b []byte) error {
+	if len(b) <= h.ChunkSize {
+		_, err := h.conn.Write(b)
+		return err
+	}
+
+	size := h.ChunkSize - gelfChunkHeaderSize
+	if size <= 0 {
+		return fmt.Errorf("chunk size %d does not exceed the chunk header", h.ChunkSize)
+	}
+	count := (len(b) + size - 1) / size
+	if count > gelfMaxChunks {
+		return fmt.Errorf("message of %d bytes exceeds %d chunks", len(b), gelfMaxChunks)
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+
+	chunk := make([]byte, 0, h.ChunkSize)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * size
+		if end > len(b) {
+			end = len(b)
+		}
+
+		chunk = append(chunk[:0], gelfChunkMagic...)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, b[i*size:end]...)
+
+		if _, err := h.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
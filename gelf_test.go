@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// listenGELF starts a UDP listener and a GELF handler sending to it.
+func listenGELF(t *testing.T) (net.PacketConn, *GELFHandler) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := NewGELFHandler(conn.LocalAddr().String())
+	if err != nil {
+		conn.Close()
+		t.Fatal(err)
+	}
+	h.SetFormatter(&messageFormatter{})
+	return conn, h
+}
+
+// readDatagram reads a single datagram from conn.
+func readDatagram(t *testing.T, conn net.PacketConn) []byte {
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf[:n]
+}
+
+func TestGELFHandler_Handle(t *testing.T) {
+	conn, h := listenGELF(t)
+	defer conn.Close()
+	defer h.Close()
+
+	h.Handle(&Record{
+		Format:     "hello %s\n",
+		Args:       []interface{}{"graylog"},
+		LoggerName: "gelf",
+		Level:      ERROR,
+		Time:       time.Unix(1500000000, 500000000),
+		Fields:     Fields{"user": "bob", "id": 1},
+	})
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(readDatagram(t, conn), &m); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"version":       "1.1",
+		"host":          h.hostname,
+		"short_message": "hello graylog",
+		"timestamp":     1500000000.5,
+		"level":         float64(3),
+		"_logger":       "gelf",
+		"_user":         "bob",
+	}
+	for k, v := range expected {
+		if m[k] != v {
+			t.Errorf("expected %s to be %v got %v", k, v, m[k])
+		}
+	}
+	if _, ok := m["_id"]; ok {
+		t.Errorf("reserved _id field is sent")
+	}
+}
+
+func TestGELFHandler_FieldValues(t *testing.T) {
+	conn, h := listenGELF(t)
+	defer conn.Close()
+	defer h.Close()
+
+	err := h.Handle(&Record{
+		Format: "fields",
+		Level:  INFO,
+		Time:   time.Now(),
+		Fields: Fields{
+			"count": 3,
+			"ratio": 0.5,
+			"err":   errors.New("timeout"),
+			"tags":  []string{"a", "b"},
+			"ch":    make(chan int),
+			"ok":    true,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(readDatagram(t, conn), &m); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"_count": float64(3),
+		"_ratio": 0.5,
+		"_err":   "timeout",
+		"_tags":  "[a b]",
+		"_ok":    "true",
+	}
+	for k, v := range expected {
+		if m[k] != v {
+			t.Errorf("expected %s to be %v got %v", k, v, m[k])
+		}
+	}
+	if _, ok := m["_ch"].(string); !ok {
+		t.Errorf("expected the channel as a string got %v", m["_ch"])
+	}
+}
+
+func TestGELFHandler_Chunks(t *testing.T) {
+	conn, h := listenGELF(t)
+	defer conn.Close()
+	defer h.Close()
+	h.ChunkSize = 500
+
+	message := strings.Repeat("x", 2000)
+	h.Handle(&Record{Format: message, Level: INFO, Time: time.Now()})
+
+	var id []byte
+	var payload []byte
+	for i, count := 0, 1; i < count; i++ {
+		chunk := readDatagram(t, conn)
+		if len(chunk) > h.ChunkSize {
+			t.Errorf("chunk of %d bytes exceeds the chunk size", len(chunk))
+		}
+		if !bytes.Equal(chunk[:2], gelfChunkMagic) {
+			t.Fatalf("missing chunk magic in %x", chunk[:gelfChunkHeaderSize])
+		}
+		if i == 0 {
+			id = chunk[2:10]
+			count = int(chunk[11])
+		}
+		if !bytes.Equal(chunk[2:10], id) || int(chunk[10]) != i || int(chunk[11]) != count {
+			t.Fatalf("unexpected chunk header %x", chunk[:gelfChunkHeaderSize])
+		}
+		payload = append(payload, chunk[gelfChunkHeaderSize:]...)
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(payload, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m["short_message"] != message {
+		t.Errorf("message is not reassembled")
+	}
+}
+
+func TestGELFHandler_SmallChunkSize(t *testing.T) {
+	conn, h := listenGELF(t)
+	defer conn.Close()
+	defer h.Close()
+
+	for _, size := range []int{gelfChunkHeaderSize, 5, 0} {
+		h.ChunkSize = size
+		if err := h.Handle(&Record{Format: "message", Level: INFO, Time: time.Now()}); err == nil {
+			t.Errorf("expected an error for chunk size %d", size)
+		}
+	}
+}
@@ -1,3 +1,3 @@
-module github.com/ducksoso/logger
+module github.com/ducksoso/logger/v2
 
 go 1.16
@@ -0,0 +1,191 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// HTTPHandler posts the logger output in batches to an HTTP endpoint.
+//
+// Records are collected until BatchSize records are pending or the flush
+// interval elapses, whichever comes first, and are then posted as a JSON
+// array of the formatted records. The default formatter is JSONFormatter;
+// the output of formatters not producing JSON objects is posted as the
+// message of an object holding the time, level and logger of the record.
+// Failed posts are retried with exponential backoff.
+type HTTPHandler struct {
+	*BaseHandler
+
+	// Client is the HTTP client used to post batches.
+	Client *http.Client
+
+	// BatchSize is the number of records that triggers a post.
+	BatchSize int
+
+	// MaxRetries is the number of times a failed post is retried.
+	MaxRetries int
+
+	// RetryBackoff is the wait before the first retry, doubled on each retry.
+	RetryBackoff time.Duration
+
+	// Gzip enables compressing the posted batches.
+	Gzip bool
+
+	url         string
+	contentType string
+	encode      func([]batchEntry) ([]byte, error)
+	batcher     *batcher
+}
+
+// NewHTTPHandler creates a new HTTP handler posting batches of up to
+// batchSize records to url at least every flushInterval. A non-positive
+// flushInterval disables the periodic posts.
+func NewHTTPHandler(url string, batchSize int, flushInterval time.Duration) *HTTPHandler {
+	h := newHTTPHandler(url, batchSize, flushInterval, "application/json", encodeJSONArray)
+	h.Formatter = &JSONFormatter{}
+	return h
+}
+
+// newHTTPHandler creates a new HTTP handler posting the batches encoded with encode.
+func newHTTPHandler(url string, batchSize int, flushInterval time.Duration,
+	contentType string, encode func([]batchEntry) ([]byte, error)) *HTTPHandler {
+	h := &HTTPHandler{
+		BaseHandler:  NewBaseHandler(),
+		Client:       http.DefaultClient,
+		BatchSize:    batchSize,
+		MaxRetries:   3,
+		RetryBackoff: 100 * time.Millisecond,
+		url:          url,
+		contentType:  contentType,
+		encode:       encode,
+	}
+	h.batcher = newBatcher("HTTPHandler", flushInterval, h.split, h.post)
+	return h
+}
+
+// Handle adds the record to the pending batch. Posting the batch happens in
+// the background, its failures are reported on stderr or by Flush.
+func (h *HTTPHandler) Handle(rec *Record) error {
+	message := h.BaseHandler.FilterAndFormat(rec)
+	if message == "" {
+		return nil
+	}
+	return h.batcher.add(batchEntry{rec: *rec, message: strings.TrimSuffix(message, "\n")})
+}
+
+// Flush posts the pending records and returns the error of the last failed post.
+func (h *HTTPHandler) Flush() error {
+	return h.batcher.flush(true)
+}
+
+// Close posts the pending batch and stops the handler. Closing it again
+// has no effect.
+func (h *HTTPHandler) Close() {
+	h.batcher.close()
+}
+
+// split returns the next batch of up to BatchSize records.
+func (h *HTTPHandler) split(pending []batchEntry) (int, bool) {
+	return splitCount(pending, h.BatchSize)
+}
+
+// post sends the batch, retrying failed attempts.
+func (h *HTTPHandler) post(batch []batchEntry) error {
+	body, err := h.encode(batch)
+	if err != nil {
+		return err
+	}
+	if h.Gzip {
+		if body, err = gzipBytes(body); err != nil {
+			return err
+		}
+	}
+
+	backoff := h.RetryBackoff
+	for i := 0; ; i++ {
+		err = h.send(body)
+		if err == nil || i >= h.MaxRetries {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// send posts body once.
+func (h *HTTPHandler) send(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", h.contentType)
+	if h.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// httpRecord is the JSON object posted for a record whose formatted message
+// is not a JSON object.
+type httpRecord struct {
+	Time    time.Time `json:"time"`
+	Level   string    `json:"level"`
+	Logger  string    `json:"logger"`
+	Message string    `json:"message"`
+}
+
+// encodeJSONArray encodes the batch as a JSON array. Formatted records that
+// are JSON objects are added as is, the others are wrapped in an httpRecord.
+func encodeJSONArray(batch []batchEntry) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('[')
+	for i, e := range batch {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if strings.HasPrefix(e.message, "{") && json.Valid([]byte(e.message)) {
+			b.WriteString(e.message)
+			continue
+		}
+		obj, err := json.Marshal(httpRecord{
+			Time:    e.rec.Time,
+			Level:   e.rec.Level.String(),
+			Logger:  e.rec.LoggerName,
+			Message: e.message,
+		})
+		if err != nil {
+			return nil, err
+		}
+		b.Write(obj)
+	}
+	b.WriteByte(']')
+	return b.Bytes(), nil
+}
+
+// gzipBytes returns b compressed with gzip.
+func gzipBytes(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(b); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
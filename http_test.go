@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// batchServer records the JSON arrays posted to it.
+type batchServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	batches [][]map[string]interface{}
+	fail    int // fail is the number of requests to fail
+}
+
+func newBatchServer() *batchServer {
+	s := &batchServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.fail > 0 {
+			s.fail--
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		b, _ := ioutil.ReadAll(r.Body)
+		var batch []map[string]interface{}
+		if err := json.Unmarshal(b, &batch); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.batches = append(s.batches, batch)
+	}))
+	return s
+}
+
+// sizes returns the number of records in each batch.
+func (s *batchServer) sizes() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var sizes []int
+	for _, b := range s.batches {
+		sizes = append(sizes, len(b))
+	}
+	return sizes
+}
+
+func TestHTTPHandler_Batch(t *testing.T) {
+	s := newBatchServer()
+	defer s.Close()
+
+	h := NewHTTPHandler(s.URL, 3, time.Hour)
+	l := NewLogger("http")
+	l.SetHandler(h)
+	for i := 0; i < 7; i++ {
+		l.Info("record %d", i)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(s.sizes()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if sizes := s.sizes(); len(sizes) != 2 || sizes[0] != 3 || sizes[1] != 3 {
+		t.Errorf("expected two full batches before close got %v", sizes)
+	}
+
+	h.Close()
+	if sizes := s.sizes(); len(sizes) != 3 || sizes[2] != 1 {
+		t.Errorf("expected the pending record to be posted on close got %v", sizes)
+	}
+
+	rec := s.batches[2][0]
+	if rec["message"] != "record 6" || rec["logger"] != "http" || rec["level"] != "INFO" {
+		t.Errorf("unexpected record %v", rec)
+	}
+}
+
+func TestHTTPHandler_FlushInterval(t *testing.T) {
+	s := newBatchServer()
+	defer s.Close()
+
+	h := NewHTTPHandler(s.URL, 100, 10*time.Millisecond)
+	defer h.Close()
+	h.Handle(&Record{Format: "record", Level: INFO})
+
+	deadline := time.Now().Add(time.Second)
+	for len(s.sizes()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if sizes := s.sizes(); len(sizes) != 1 || sizes[0] != 1 {
+		t.Errorf("expected the record to be posted after the flush interval got %v", sizes)
+	}
+}
+
+func TestHTTPHandler_Retry(t *testing.T) {
+	s := newBatchServer()
+	defer s.Close()
+	s.fail = 2
+
+	h := NewHTTPHandler(s.URL, 100, time.Hour)
+	h.RetryBackoff = time.Millisecond
+	h.Handle(&Record{Format: "record", Level: INFO})
+	h.Close()
+
+	if sizes := s.sizes(); len(sizes) != 1 {
+		t.Errorf("expected the batch to be posted after retrying got %v", sizes)
+	}
+}
+
+func TestHTTPHandler_Flush(t *testing.T) {
+	s := newBatchServer()
+	defer s.Close()
+
+	h := NewHTTPHandler(s.URL, 100, time.Hour)
+	defer h.Close()
+	h.Handle(&Record{Format: "record", Level: INFO})
+
+	if err := h.Flush(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if sizes := s.sizes(); len(sizes) != 1 || sizes[0] != 1 {
+		t.Errorf("expected the record to be posted on flush got %v", sizes)
+	}
+}
+
+func TestHTTPHandler_TextFormatter(t *testing.T) {
+	s := newBatchServer()
+	defer s.Close()
+
+	h := NewHTTPHandler(s.URL, 100, time.Hour)
+	h.SetFormatter(&LogfmtFormatter{})
+	l := NewLogger("http")
+	l.SetHandler(h)
+	l.Info("record")
+	h.Close()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.batches) != 1 || len(s.batches[0]) != 1 {
+		t.Fatalf("expected a valid JSON batch got %v", s.batches)
+	}
+	rec := s.batches[0][0]
+	msg, _ := rec["message"].(string)
+	if !strings.Contains(msg, "msg=record") || rec["logger"] != "http" || rec["level"] != "INFO" {
+		t.Errorf("expected the logfmt output wrapped in an object got %v", rec)
+	}
+}
+
+func TestHTTPHandler_NoFlushInterval(t *testing.T) {
+	s := newBatchServer()
+	defer s.Close()
+
+	h := NewHTTPHandler(s.URL, 100, 0)
+	h.Handle(&Record{Format: "record", Level: INFO})
+	h.Close()
+	h.Close()
+
+	if sizes := s.sizes(); len(sizes) != 1 || sizes[0] != 1 {
+		t.Errorf("expected the record to be posted on close got %v", sizes)
+	}
+}
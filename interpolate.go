@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"container/list"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// interpolationCache is a LRU cache of rendered messages keyed by their
+// format and arguments.
+type interpolationCache struct {
+	mu    sync.Mutex
+	size  int
+	lru   *list.List               // lru holds the entries, most recently used first
+	items map[string]*list.Element // items maps the keys to their lru elements
+}
+
+// cacheEntry is an entry of an interpolationCache.
+type cacheEntry struct {
+	key, message string
+}
+
+// messageCache holds the *interpolationCache of the package, nil if caching
+// is disabled.
+var messageCache atomic.Value
+
+// SetInterpolationCache enables caching the last size rendered messages, so
+// repeated log lines with the same format and arguments are formatted once.
+// Only messages whose arguments are strings, booleans and numbers are
+// cached. A size of zero disables the cache, which is the default.
+func SetInterpolationCache(size int) {
+	var c *interpolationCache
+	if size > 0 {
+		c = &interpolationCache{
+			size:  size,
+			lru:   list.New(),
+			items: make(map[string]*list.Element),
+		}
+	}
+	messageCache.Store(c)
+}
+
+// interpolate formats the message in the manner of fmt.Sprintf, using the
+// cache when it is enabled.
+func interpolate(format string, args []interface{}) string {
+	c, _ := messageCache.Load().(*interpolationCache)
+	if c == nil {
+		return fmt.Sprintf(format, args...)
+	}
+
+	var buf [128]byte
+	key, ok := appendCacheKey(buf[:0], format, args)
+	if !ok {
+		return fmt.Sprintf(format, args...)
+	}
+
+	c.mu.Lock()
+	if e, ok := c.items[string(key)]; ok {
+		c.lru.MoveToFront(e)
+		c.mu.Unlock()
+		return e.Value.(*cacheEntry).message
+	}
+	c.mu.Unlock()
+
+	message := fmt.Sprintf(format, args...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[string(key)]; !ok {
+		for c.lru.Len() >= c.size {
+			oldest := c.lru.Remove(c.lru.Back()).(*cacheEntry)
+			delete(c.items, oldest.key)
+		}
+		e := &cacheEntry{key: string(key), message: message}
+		c.items[e.key] = c.lru.PushFront(e)
+	}
+	return message
+}
+
+// appendCacheKey appends the key identifying the message of format and args
+// to b. It reports false if an argument is not of a type the key can be built
+// from. The arguments are tagged with their type since their rendering
+// depends on it, e.g. for %v of int8(1) and 1.0.
+func appendCacheKey(b []byte, format string, args []interface{}) ([]byte, bool) {
+	b = strconv.AppendInt(b, int64(len(format)), 10)
+	b = append(b, ':')
+	b = append(b, format...)
+
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case string:
+			b = append(b, 's')
+			b = strconv.AppendInt(b, int64(len(v)), 10)
+			b = append(b, ':')
+			b = append(b, v...)
+		case bool:
+			b = append(b, 'b')
+			b = strconv.AppendBool(b, v)
+		case int:
+			b = strconv.AppendInt(append(b, 'i'), int64(v), 10)
+		case int8:
+			b = strconv.AppendInt(append(b, '1'), int64(v), 10)
+		case int16:
+			b = strconv.AppendInt(append(b, '2'), int64(v), 10)
+		case int32:
+			b = strconv.AppendInt(append(b, '4'), int64(v), 10)
+		case int64:
+			b = strconv.AppendInt(append(b, '8'), v, 10)
+		case uint:
+			b = strconv.AppendUint(append(b, 'u'), uint64(v), 10)
+		case uint8:
+			b = strconv.AppendUint(append(b, 'B'), uint64(v), 10)
+		case uint16:
+			b = strconv.AppendUint(append(b, 'W'), uint64(v), 10)
+		case uint32:
+			b = strconv.AppendUint(append(b, 'D'), uint64(v), 10)
+		case uint64:
+			b = strconv.AppendUint(append(b, 'Q'), v, 10)
+		case float32:
+			b = strconv.AppendFloat(append(b, 'f'), float64(v), 'g', -1, 32)
+		case float64:
+			b = strconv.AppendFloat(append(b, 'F'), v, 'g', -1, 64)
+		default:
+			return b, false
+		}
+		b = append(b, ';')
+	}
+	return b, true
+}
@@ -0,0 +1,90 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestInterpolationCache(t *testing.T) {
+	SetInterpolationCache(2)
+	defer SetInterpolationCache(0)
+
+	tests := []struct {
+		format string
+		args   []interface{}
+	}{
+		{"user %v logged in", []interface{}{"bob"}},
+		{"user %v logged in", []interface{}{"alice"}},
+		{"user %v logged in", []interface{}{"bob"}},
+		{"count %v", []interface{}{1}},
+		{"count %v", []interface{}{int8(1)}},
+		{"count %v", []interface{}{1.5}},
+		{"count %v", []interface{}{float32(1.5)}},
+		{"count %v", []interface{}{uint(7)}},
+		{"pair %v %v", []interface{}{"a;", "b"}},
+		{"pair %v %v", []interface{}{"a", ";b"}},
+		{"flag %v", []interface{}{true}},
+		{"flag %v", []interface{}{false}},
+		{"value %v", []interface{}{[]int{1, 2}}},
+		{"value %v", []interface{}{[]int{3}}},
+		{"user %v logged in", []interface{}{"alice"}},
+	}
+	for i := 0; i < 2; i++ {
+		for _, test := range tests {
+			expected := fmt.Sprintf(test.format, test.args...)
+			if msg := interpolate(test.format, test.args); msg != expected {
+				t.Errorf("expected %q got %q", expected, msg)
+			}
+		}
+	}
+
+	c := messageCache.Load().(*interpolationCache)
+	if n := c.lru.Len(); n != 2 {
+		t.Errorf("expected the cache to be capped at 2 entries got %d", n)
+	}
+}
+
+func TestAppendCacheKey(t *testing.T) {
+	distinct := [][]interface{}{
+		{1}, {int8(1)}, {int64(1)}, {uint(1)}, {uint64(1)}, {"1"}, {1.0}, {float32(1)}, {true},
+		{"a", "b"}, {"ab"}, {"a;", "b"}, {"a", ";b"},
+	}
+	seen := map[string]int{}
+	for i, args := range distinct {
+		key, ok := appendCacheKey(nil, "%v", args)
+		if !ok {
+			t.Errorf("expected %v to be cacheable", args)
+			continue
+		}
+		if j, ok := seen[string(key)]; ok {
+			t.Errorf("arguments %v and %v share the key %q", distinct[j], args, key)
+		}
+		seen[string(key)] = i
+	}
+
+	if _, ok := appendCacheKey(nil, "%v", []interface{}{struct{}{}}); ok {
+		t.Errorf("expected a struct not to be cacheable")
+	}
+	if _, ok := appendCacheKey(nil, "%v", []interface{}{level(1)}); ok {
+		t.Errorf("expected a named type not to be cacheable")
+	}
+}
+
+func benchmarkInterpolate(b *testing.B, size int) {
+	SetInterpolationCache(size)
+	defer SetInterpolationCache(0)
+
+	args := []interface{}{"GET", "/api/v1/users", 200, 12.5, true}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		interpolate("%-6s %q status=%03d latency=%8.3fms cached=%t", args)
+	}
+}
+
+func BenchmarkInterpolate_NoCache(b *testing.B) {
+	benchmarkInterpolate(b, 0)
+}
+
+func BenchmarkInterpolate_Cache(b *testing.B) {
+	benchmarkInterpolate(b, 128)
+}
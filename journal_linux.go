@@ -0,0 +1,167 @@
+//go:build linux
+// +build linux
+
+package logger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// journalSocket is the socket of the native protocol of systemd-journald.
+const journalSocket = "/run/systemd/journal/socket"
+
+// JournalHandler sends the logger output to the systemd journal with the
+// native protocol. The level is sent as PRIORITY and the structured fields
+// as additional journal fields, upper cased.
+//
+// NewJournalHandler fails when the journal is not available, e.g. on
+// systems without systemd, so callers can fall back to another handler:
+//
+//	h, err := logger.NewJournalHandler("app")
+//	if err != nil {
+//		logger.SetHandler(logger.StderrHandler)
+//	} else {
+//		logger.SetHandler(h)
+//	}
+type JournalHandler struct {
+	*BaseHandler
+	mu         sync.Mutex
+	conn       *net.UnixConn
+	addr       *net.UnixAddr
+	identifier string
+}
+
+// NewJournalHandler creates a new journal handler sending records with the
+// given syslog identifier.
+func NewJournalHandler(identifier string) (*JournalHandler, error) {
+	return newJournalHandler(journalSocket, identifier)
+}
+
+// newJournalHandler creates a new journal handler sending to the socket at path.
+func newJournalHandler(path, identifier string) (*JournalHandler, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("JournalHandler can not find journal socket: %s", err)
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &JournalHandler{
+		BaseHandler: NewBaseHandler(),
+		conn:        conn,
+		addr:        &net.UnixAddr{Name: path, Net: "unixgram"},
+		identifier:  identifier,
+	}, nil
+}
+
+func (h *JournalHandler) Handle(rec *Record) error {
+	message := h.BaseHandler.FilterAndFormat(rec)
+	if message == "" {
+		return nil
+	}
+
+	b := journalMessage(rec, strings.TrimSuffix(message, "\n"), h.identifier)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, err := h.conn.WriteToUnix(b, h.addr); err != nil {
+		return fmt.Errorf("JournalHandler can not send record: %s", err)
+	}
+	return nil
+}
+
+// Close closes the connection to the journal.
+func (h *JournalHandler) Close() {
+	h.conn.Close()
+}
+
+// journalMessage returns the datagram of the record in the journal native
+// protocol.
+func journalMessage(rec *Record, message, identifier string) []byte {
+	var b []byte
+	b = appendJournalField(b, "MESSAGE", message)
+	b = appendJournalField(b, "PRIORITY", strconv.Itoa(severity(rec.Level)))
+	if identifier != "" {
+		b = appendJournalField(b, "SYSLOG_IDENTIFIER", identifier)
+	}
+	b = appendJournalField(b, "LOGGER", rec.LoggerName)
+	if rec.Filename != "" {
+		b = appendJournalField(b, "CODE_FILE", rec.Filename)
+		b = appendJournalField(b, "CODE_LINE", strconv.Itoa(rec.Line))
+	}
+	if rec.Function != "" {
+		b = appendJournalField(b, "CODE_FUNC", rec.Function)
+	}
+
+	keys := make([]string, 0, len(rec.Fields))
+	for k := range rec.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if name := journalFieldName(k); name != "" {
+			b = appendJournalField(b, name, fmt.Sprint(rec.Fields[k]))
+		}
+	}
+	return b
+}
+
+// appendJournalField appends the field to b. Values containing a newline
+// are sent with their length instead of being newline terminated.
+func appendJournalField(b []byte, name, value string) []byte {
+	b = append(b, name...)
+	if !strings.Contains(value, "\n") {
+		b = append(b, '=')
+		b = append(b, value...)
+		return append(b, '\n')
+	}
+
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	b = append(b, '\n')
+	b = append(b, size[:]...)
+	b = append(b, value...)
+	return append(b, '\n')
+}
+
+// journalFieldName returns the journal field name of a structured field:
+// upper cased, with the characters other than letters, digits and
+// underscores replaced by underscores. Leading underscores and digits are
+// dropped since they are reserved or invalid. Names of the fields written by
+// the handler or with a meaning to journald, such as MESSAGE or CODE_FILE,
+// are prefixed with "F_" so they do not clash with them.
+func journalFieldName(key string) string {
+	name := []byte(strings.ToUpper(key))
+	for i, c := range name {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			name[i] = '_'
+		}
+	}
+	s := strings.TrimLeft(string(name), "_0123456789")
+	if journalReserved(s) {
+		s = "F_" + s
+	}
+	if len(s) > 64 {
+		s = s[:64]
+	}
+	return s
+}
+
+// journalReserved reports whether name is written by the handler or has a
+// meaning to journald.
+func journalReserved(name string) bool {
+	switch name {
+	case "MESSAGE", "MESSAGE_ID", "PRIORITY", "LOGGER", "ERRNO", "TID", "DOCUMENTATION":
+		return true
+	}
+	return strings.HasPrefix(name, "CODE_") || strings.HasPrefix(name, "SYSLOG_")
+}
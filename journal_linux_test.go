@@ -0,0 +1,93 @@
+//go:build linux
+// +build linux
+
+package logger
+
+import (
+	"bytes"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJournalHandler_Handle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	h, err := newJournalHandler(path, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.SetLevel(DEBUG)
+	h.SetFormatter(&messageFormatter{})
+
+	priorities := map[level]string{
+		CRITICAL: "2",
+		ERROR:    "3",
+		WARNING:  "4",
+		NOTICE:   "5",
+		INFO:     "6",
+		DEBUG:    "7",
+	}
+	buf := make([]byte, 65536)
+	for l, priority := range priorities {
+		err := h.Handle(&Record{
+			Format:     "hello\n",
+			LoggerName: "journal",
+			Level:      l,
+			Fields:     Fields{"user-id": 42, "_hidden": true},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := "MESSAGE=hello\nPRIORITY=" + priority + "\nSYSLOG_IDENTIFIER=app\nLOGGER=journal\nHIDDEN=true\nUSER_ID=42\n"
+		if got := string(buf[:n]); got != expected {
+			t.Errorf("%s: expected %q got %q", l, expected, got)
+		}
+	}
+}
+
+func TestJournalMessage_ReservedFields(t *testing.T) {
+	rec := &Record{
+		Level:      INFO,
+		LoggerName: "journal",
+		Fields:     Fields{"message": "m", "priority": 1, "code_file": "f", "syslog_identifier": "s", "user": "bob"},
+	}
+	b := journalMessage(rec, "hello", "")
+
+	expected := "MESSAGE=hello\nPRIORITY=6\nLOGGER=journal\n" +
+		"F_CODE_FILE=f\nF_MESSAGE=m\nF_PRIORITY=1\nF_SYSLOG_IDENTIFIER=s\nUSER=bob\n"
+	if got := string(b); got != expected {
+		t.Errorf("expected %q got %q", expected, got)
+	}
+}
+
+func TestJournalMessage_Multiline(t *testing.T) {
+	b := journalMessage(&Record{Level: INFO}, "first\nsecond", "")
+
+	expected := []byte("MESSAGE\n\x0c\x00\x00\x00\x00\x00\x00\x00first\nsecond\n")
+	if !bytes.HasPrefix(b, expected) {
+		t.Errorf("expected prefix %q got %q", expected, b)
+	}
+}
+
+func TestNewJournalHandler_NoSocket(t *testing.T) {
+	_, err := newJournalHandler(filepath.Join(t.TempDir(), "missing"), "app")
+	if err == nil || !strings.Contains(err.Error(), "can not find journal socket") {
+		t.Errorf("expected missing socket error got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// JSONSchemaVersion is the version of the JSONFormatter output, added as
+// "schema" when enabled. It is bumped whenever fields are renamed, removed or
+// change type.
+const JSONSchemaVersion = 1
+
+// JSONFormatter formats records as single line JSON objects.
+//
+// When an argument of the record is an error, its message is added as
+// "error" and the messages of the errors it wraps as "error_chain". The
+// structured fields of the record are added as the "fields" object.
+type JSONFormatter struct {
+	// DisableCaller omits the file and line of the log call from the output.
+	DisableCaller bool
+
+	// Schema adds the JSONSchemaVersion to the output.
+	Schema bool
+}
+
+// jsonRecord is the JSON representation of a record.
+type jsonRecord struct {
+	Schema     int      `json:"schema,omitempty"`
+	Time       string   `json:"time"`
+	Level      string   `json:"level"`
+	Logger     string   `json:"logger"`
+	Message    string   `json:"message"`
+	File       string   `json:"file,omitempty"`
+	Line       int      `json:"line,omitempty"`
+	Function   string   `json:"function,omitempty"`
+	Host       string   `json:"host,omitempty"`
+	PID        int      `json:"pid"`
+	Goroutine  uint64   `json:"goroutine,omitempty"`
+	Stack      string   `json:"stack,omitempty"`
+	Error      string   `json:"error,omitempty"`
+	ErrorChain []string `json:"error_chain,omitempty"`
+
+	Fields map[string]json.RawMessage `json:"fields,omitempty"`
+}
+
+func (f *JSONFormatter) Format(rec *Record) string {
+	r := jsonRecord{
+		Time:      rec.Time.Format(time.RFC3339),
+		Level:     rec.Level.String(),
+		Logger:    rec.LoggerName,
+		Message:   truncateMessage(rec.Message()),
+		Host:      rec.Hostname,
+		PID:       rec.ProcessID,
+		Goroutine: rec.GoroutineID,
+		Stack:     rec.Stack,
+		Fields:    jsonFields(rec.Fields),
+	}
+	if f.Schema {
+		r.Schema = JSONSchemaVersion
+	}
+	if err := recordError(rec); err != nil {
+		r.Error = err.Error()
+		r.ErrorChain = errorChain(err)
+	}
+	if !f.DisableCaller {
+		r.File = rec.Filename
+		r.Line = rec.Line
+		r.Function = rec.Function
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// jsonFields returns the fields encoded as JSON values, nil if there are
+// none. Errors are encoded as their message, and the values JSON can not
+// encode as strings in the manner of fmt.Sprint, so a field never prevents
+// a record from being formatted.
+func jsonFields(fields Fields) map[string]json.RawMessage {
+	if len(fields) == 0 {
+		return nil
+	}
+	m := make(map[string]json.RawMessage, len(fields))
+	for k, v := range fields {
+		m[k] = jsonValue(v)
+	}
+	return m
+}
+
+// jsonValue returns the JSON encoding of a field value.
+func jsonValue(v interface{}) json.RawMessage {
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(v))
+	}
+	return b
+}
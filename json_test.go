@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONFormatter_Format(t *testing.T) {
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	rec := &Record{
+		Format:     "hello %s\n",
+		Args:       []interface{}{"world"},
+		LoggerName: "json",
+		Level:      WARNING,
+		Time:       now,
+		Filename:   "/src/app/main.go",
+		Line:       12,
+		ProcessID:  34,
+	}
+
+	out := (&JSONFormatter{}).Format(rec)
+	if strings.Contains(out, "\n") {
+		t.Errorf("expected a single line got %q", out)
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &m); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"time":    now.Format(time.RFC3339),
+		"level":   "WARNING",
+		"logger":  "json",
+		"message": "hello world",
+		"file":    "/src/app/main.go",
+		"line":    float64(12),
+		"pid":     float64(34),
+	}
+	for k, v := range expected {
+		if m[k] != v {
+			t.Errorf("expected %s to be %v got %v", k, v, m[k])
+		}
+	}
+
+	out = (&JSONFormatter{DisableCaller: true}).Format(rec)
+	if strings.Contains(out, `"file"`) || strings.Contains(out, `"line"`) {
+		t.Errorf("caller should be omitted: %q", out)
+	}
+}
+
+func TestJSONFormatter_ErrorChain(t *testing.T) {
+	root := errors.New("connection refused")
+	err := fmt.Errorf("query users: %w", fmt.Errorf("dial db: %w", root))
+	rec := &Record{Format: "request failed: %v\n", Args: []interface{}{err}, Level: ERROR}
+
+	var m struct {
+		Error      string   `json:"error"`
+		ErrorChain []string `json:"error_chain"`
+	}
+	if err := json.Unmarshal([]byte((&JSONFormatter{}).Format(rec)), &m); err != nil {
+		t.Fatal(err)
+	}
+
+	if m.Error != "query users: dial db: connection refused" {
+		t.Errorf("unexpected error %q", m.Error)
+	}
+	expected := []string{"dial db: connection refused", "connection refused"}
+	if fmt.Sprint(m.ErrorChain) != fmt.Sprint(expected) {
+		t.Errorf("expected chain %q got %q", expected, m.ErrorChain)
+	}
+}
+
+func BenchmarkJSONFormatter(b *testing.B) {
+	benchmarkFormatter(b, &JSONFormatter{})
+}
+
+func TestJSONFormatter_Fields(t *testing.T) {
+	rec := &Record{
+		Format: "login\n",
+		Level:  INFO,
+		Fields: Fields{
+			"user":    "bob",
+			"attempt": 2,
+			"err":     errors.New("denied"),
+			"ch":      make(chan int),
+		},
+	}
+
+	var m struct {
+		Fields map[string]interface{} `json:"fields"`
+	}
+	out := (&JSONFormatter{}).Format(rec)
+	if err := json.Unmarshal([]byte(out), &m); err != nil {
+		t.Fatalf("invalid JSON %q: %s", out, err)
+	}
+
+	if m.Fields["user"] != "bob" || m.Fields["attempt"] != float64(2) || m.Fields["err"] != "denied" {
+		t.Errorf("unexpected fields %v", m.Fields)
+	}
+	if s, ok := m.Fields["ch"].(string); !ok || !strings.HasPrefix(s, "0x") {
+		t.Errorf("expected the unencodable value as a string got %v", m.Fields["ch"])
+	}
+
+	if out := (&JSONFormatter{}).Format(&Record{Format: "no fields"}); strings.Contains(out, `"fields"`) {
+		t.Errorf("expected no fields object got %q", out)
+	}
+}
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// LogfmtFormatter formats records as logfmt key=value pairs, e.g.
+//
+//	time=2006-01-02T15:04:05Z level=INFO logger=app msg="hello world" user=bob
+//
+// The structured fields of the record follow the message, sorted by key.
+// When an argument of the record is an error, its message is added as error
+// and the messages of the errors it wraps as error.1, error.2 and so on.
+type LogfmtFormatter struct{}
+
+func (f *LogfmtFormatter) Format(rec *Record) string {
+	var b strings.Builder
+
+	writeLogfmt(&b, "time", rec.Time.Format(time.RFC3339))
+	writeLogfmt(&b, "level", rec.Level.String())
+	writeLogfmt(&b, "logger", rec.LoggerName)
+	writeLogfmt(&b, "msg", truncateMessage(rec.Message()))
+	if rec.GoroutineID != 0 {
+		writeLogfmt(&b, "goroutine", strconv.FormatUint(rec.GoroutineID, 10))
+	}
+	if rec.Stack != "" {
+		writeLogfmt(&b, "stack", rec.Stack)
+	}
+	if err := recordError(rec); err != nil {
+		writeLogfmt(&b, "error", err.Error())
+		for i, cause := range errorChain(err) {
+			writeLogfmt(&b, "error."+strconv.Itoa(i+1), cause)
+		}
+	}
+
+	for _, k := range sortedKeys(rec.Fields) {
+		writeLogfmt(&b, k, fmt.Sprint(rec.Fields[k]))
+	}
+
+	return b.String()
+}
+
+// writeLogfmt writes a key=value pair to b, separated from the previous pair
+// with a space.
+func writeLogfmt(b *strings.Builder, key, value string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(key)
+	b.WriteByte('=')
+	if needsQuoting(value) {
+		b.WriteString(strconv.Quote(value))
+	} else {
+		b.WriteString(value)
+	}
+}
+
+// appendFields appends the fields to b as logfmt key=value pairs sorted by
+// key, each preceded by a space. The text formatters end their lines with
+// them.
+func appendFields(b []byte, fields Fields) []byte {
+	for _, k := range sortedKeys(fields) {
+		b = append(b, ' ')
+		b = append(b, k...)
+		b = append(b, '=')
+		if value := fmt.Sprint(fields[k]); needsQuoting(value) {
+			b = strconv.AppendQuote(b, value)
+		} else {
+			b = append(b, value...)
+		}
+	}
+	return b
+}
+
+// sortedKeys returns the keys of fields in order.
+func sortedKeys(fields Fields) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// needsQuoting reports whether a logfmt value must be quoted.
+func needsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if r == ' ' || r == '=' || r == '"' || r == '\\' || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
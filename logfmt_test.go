@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogfmtFormatter_Format(t *testing.T) {
+	rec := &Record{
+		Format:     "%s\n",
+		LoggerName: "app",
+		Level:      INFO,
+		Time:       time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		Fields:     Fields{"user": "bob", "count": 3},
+	}
+
+	tests := []struct {
+		message  string
+		expected string
+	}{
+		{"plain", "msg=plain"},
+		{"with spaces", `msg="with spaces"`},
+		{"a=b", `msg="a=b"`},
+		{`say "hi"`, `msg="say \"hi\""`},
+		{"", `msg=""`},
+	}
+
+	for _, test := range tests {
+		rec.Args = []interface{}{test.message}
+		out := (&LogfmtFormatter{}).Format(rec)
+
+		expected := "time=2021-03-04T05:06:07Z level=INFO logger=app " + test.expected + " count=3 user=bob"
+		if out != expected {
+			t.Errorf("expected %q got %q", expected, out)
+		}
+	}
+}
+
+func TestLogfmtFormatter_QuotesFields(t *testing.T) {
+	rec := &Record{
+		Format: "msg",
+		Level:  INFO,
+		Fields: Fields{"query": "id = 1"},
+	}
+
+	if out := (&LogfmtFormatter{}).Format(rec); !strings.HasSuffix(out, ` query="id = 1"`) {
+		t.Errorf("field is not quoted: %q", out)
+	}
+}
+
+func TestLogfmtFormatter_ErrorChain(t *testing.T) {
+	root := errors.New("connection refused")
+	err := fmt.Errorf("query users: %w", fmt.Errorf("dial db: %w", root))
+	rec := &Record{Format: "request failed: %v\n", Args: []interface{}{err}, Level: ERROR}
+
+	out := (&LogfmtFormatter{}).Format(rec)
+	for _, expected := range []string{
+		`error="query users: dial db: connection refused"`,
+		`error.1="dial db: connection refused"`,
+		`error.2="connection refused"`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected %s in %q", expected, out)
+		}
+	}
+}
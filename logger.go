@@ -2,14 +2,21 @@
 package logger
 
 import (
+	"bytes"
+	gocontext "context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 )
 
 type (
@@ -17,6 +24,10 @@ type (
 	level int // level represents severity of logs
 )
 
+// Level is an exported alias of the level type, so that levels can be
+// declared in configuration structs outside of this package.
+type Level = level
+
 // Logger levels.
 const (
 	CRITICAL level = iota
@@ -39,6 +50,9 @@ const (
 	WHITE
 )
 
+// levelsMu guards levelNames and levelColors against RegisterLevel.
+var levelsMu sync.RWMutex
+
 // levelNames provides mapping for log levels.
 var levelNames = map[level]string{
 	CRITICAL: "CRITICAL",
@@ -59,49 +73,216 @@ var levelColors = map[level]color{
 	DEBUG:    CYAN,
 }
 
+// RegisterLevel registers a custom level with the given name and color, or
+// renames and recolors an existing one. Levels are filtered by their
+// numeric value, e.g. a TRACE level registered as DEBUG+1 is only logged by
+// loggers set to TRACE.
+func RegisterLevel(l level, name string, c color) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	levelNames[l] = name
+	levelColors[l] = c
+}
+
+// levelName returns the name of a registered level.
+func levelName(l level) (string, bool) {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+	name, ok := levelNames[l]
+	return name, ok
+}
+
+// levelColor returns the color of the level.
+func levelColor(l level) color {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+	return levelColors[l]
+}
+
+// severity returns the syslog severity of the level.
+func severity(l level) int {
+	switch {
+	case l <= CRITICAL:
+		return 2
+	case l == ERROR:
+		return 3
+	case l == WARNING:
+		return 4
+	case l == NOTICE:
+		return 5
+	case l == INFO:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// String returns the name of the level.
+func (l level) String() string {
+	if name, ok := levelName(l); ok {
+		return name
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// ParseLevel returns the level with the given name. The name is matched
+// case-insensitively.
+func ParseLevel(s string) (level, error) {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+
+	for l, name := range levelNames {
+		if strings.EqualFold(name, s) {
+			return l, nil
+		}
+	}
+	return 0, fmt.Errorf("logger: unknown level %q", s)
+}
+
+// MarshalJSON encodes the level as its name.
+func (l level) MarshalJSON() ([]byte, error) {
+	if _, ok := levelName(l); !ok {
+		return nil, fmt.Errorf("logger: unknown level %d", int(l))
+	}
+	return json.Marshal(l.String())
+}
+
+// UnmarshalJSON decodes the level from its name. Numeric values are
+// accepted for backward compatibility.
+func (l *level) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		parsed, err := ParseLevel(name)
+		if err != nil {
+			return err
+		}
+		*l = parsed
+		return nil
+	}
+
+	var n int
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("logger: invalid level %s", data)
+	}
+	if _, ok := levelName(level(n)); !ok {
+		return fmt.Errorf("logger: unknown level %d", n)
+	}
+	*l = level(n)
+	return nil
+}
+
 var (
 	// DefaultLogger holds default logger
-	DefaultLogger Logger = NewLogger(procName())
+	DefaultLogger Logger = NewLogger(pname)
 
-	// DefaultLevel holds default value for loggers
-	DefaultLevel level = INFO
+	// DefaultLevel holds default value for loggers, INFO unless set by the
+	// LOG_LEVEL environment variable
+	DefaultLevel level = loadEnvLevels()
 
 	// DefaultFormatter holds default formatter for loggers
-	DefaultFormatter Formatter = &defaultFormatter{}
+	DefaultFormatter Formatter = &TextFormatter{}
 
 	// DefaultHandler holds default handler for loggers
-	DefaultHandler Handler = stderrHandler
+	DefaultHandler Handler = StderrHandler
+
+	// FullFunctionNames records package qualified function names such as
+	// "github.com/user/pkg.Func" instead of the short "pkg.Func" form
+	FullFunctionNames = false
+
+	// DefaultTrimPath shortens the caller file names of the text formatters
+	// without a TrimPath of their own, e.g. TrimPathPrefix(root) or
+	// filepath.Base. It keeps the package directory and file name by default
+	DefaultTrimPath = shortPath
+
+	// MaxMessageLength is the length in bytes above which formatters
+	// truncate messages, marking them with a "…(truncated N bytes)" suffix.
+	// It protects the sinks from huge arguments. Zero disables truncation
+	MaxMessageLength = 0
+
+	// StdoutHandler holds a handler with outputting to stdout
+	StdoutHandler = NewWriterHandler(os.Stdout)
+
+	// StderrHandler holds a handler with outputting to stderr
+	StderrHandler = NewWriterHandler(os.Stderr)
 
-	// stdoutHandler holds a handler with outputting to stdout
-	stdoutHandler = NewWriterHandler(os.Stdout)
+	// pid, pname and hostname hold the process ID, process name and host
+	// name stamped on every record
+	pid      = os.Getpid()
+	pname    = procName()
+	hostname = hostName()
 
-	// stderrHandler holds a handler with outputting to stderr
-	stderrHandler = NewWriterHandler(os.Stderr)
+	// now returns the time records are stamped with, replaced in tests
+	now = time.Now
 )
 
 // Logger is the interface for output log messages in different levels.
 // A new Logger can be created with NewLogger() function.
 // You can changed the output handler with SetHandler() function.
+// Arguments of type func() interface{} are called to compute the value to log
+// only when the message is logged. A last argument of type Fields is attached
+// to the record as structured fields.
 type Logger interface {
+	// Name returns the name of the logger, followed by the prefixes of
+	// context loggers, e.g. "db[request=42]".
+	Name() string
+
 	// SetLevel changes the level of the logger. Default is logging.Info.
 	SetLevel(level)
 
-	// SetHandler replaces the current handler for output. Default is logger.stderrHandler.
+	// IsEnabled reports whether messages of the given level are logged, to
+	// guard the computation of expensive arguments.
+	IsEnabled(level) bool
+
+	// SetHandler replaces the current handler for output. Default is logger.StderrHandler.
 	SetHandler(Handler)
 
-	// SetCallDepth sets the parameter passed to runtime.Caller().
-	// It is used to get the file name from call stack.
+	// SetCallDepth sets the number of stack frames to skip above the first
+	// caller outside of this package. It is used to get the file name from
+	// call stack.
 	// For example you need to set it to 1 if you are using a wrapper around
 	// the Logger. Default value is zero.
 	SetCallDepth(int)
 
+	// SetTimeZone sets the location records are timestamped in. Default is
+	// the local time zone.
+	SetTimeZone(*time.Location)
+
+	// SetGoroutineID enables recording the ID of the logging goroutine.
+	// It is disabled by default because it requires parsing the stack.
+	SetGoroutineID(bool)
+
+	// SetStackTrace enables capturing the stack of the logging goroutine for
+	// records at or above the given level. It is disabled by default.
+	SetStackTrace(enabled bool, min level)
+
+	// SetErrorHook sets the function receiving the errors returned by the
+	// handler. A nil hook restores the default, which prints the errors to
+	// stderr.
+	SetErrorHook(func(error))
+
 	// New creates a new inerhited context logger with given prefixes.
 	New(prefixes ...interface{}) Logger
 
+	// WithPrefix creates a new inherited context logger prepending
+	// "[prefix]" to its messages, after the prefixes of the logger.
+	WithPrefix(prefix string) Logger
+
+	// Writer returns an io.Writer logging each line written to it at the
+	// given level, e.g. to back the *log.Logger of http.Server.ErrorLog.
+	// Partial lines are buffered until their newline is written. The
+	// writer also implements io.Closer: closing it logs a pending partial
+	// line.
+	Writer(level) io.Writer
+
 	// Fatal is equivalent to l.Critical followed by a call to os.Exit(1).
+	// The handler is flushed and closed before exiting.
 	Fatal(format string, args ...interface{})
 
+	// FatalWithCode is equivalent to l.Fatal, exiting with the given code.
+	FatalWithCode(code int, format string, args ...interface{})
+
 	// Panic is equivalent to l.Critical followed by a call to panic().
+	// The handler is flushed before panicking.
 	Panic(format string, args ...interface{})
 
 	// Critical logs a message using CRITICAL as log level.
@@ -121,6 +302,31 @@ type Logger interface {
 
 	// Debug logs a message using DEBUG as log level.
 	Debug(format string, args ...interface{})
+
+	// Log logs a message using the given level, which may be a registered custom level.
+	Log(level level, format string, args ...interface{})
+
+	// LogCtx is like Log and adds the fields of ctx to the record, see
+	// WithFields and TraceExtractor.
+	LogCtx(ctx gocontext.Context, level level, format string, args ...interface{})
+
+	// CriticalCtx logs a message using CRITICAL as log level with the fields of ctx.
+	CriticalCtx(ctx gocontext.Context, format string, args ...interface{})
+
+	// ErrorCtx logs a message using ERROR as log level with the fields of ctx.
+	ErrorCtx(ctx gocontext.Context, format string, args ...interface{})
+
+	// WarningCtx logs a message using WARNING as log level with the fields of ctx.
+	WarningCtx(ctx gocontext.Context, format string, args ...interface{})
+
+	// NoticeCtx logs a message using NOTICE as log level with the fields of ctx.
+	NoticeCtx(ctx gocontext.Context, format string, args ...interface{})
+
+	// InfoCtx logs a message using INFO as log level with the fields of ctx.
+	InfoCtx(ctx gocontext.Context, format string, args ...interface{})
+
+	// DebugCtx logs a message using DEBUG as log level with the fields of ctx.
+	DebugCtx(ctx gocontext.Context, format string, args ...interface{})
 }
 
 // Handler handles the output.
@@ -128,8 +334,14 @@ type Handler interface {
 	SetFormatter(Formatter)
 	SetLevel(level)
 
-	// Handle single log record.
-	Handle(*Record)
+	// Handle single log record. The record is recycled once Handle returns,
+	// handlers keeping it for later, e.g. to process it asynchronously,
+	// must keep a copy.
+	Handle(*Record) error
+
+	// Flush writes out the records buffered by the handler, if any, and
+	// blocks until they are handled.
+	Flush() error
 
 	// Close the handler.
 	Close()
@@ -141,14 +353,51 @@ type Record struct {
 	Args        []interface{} // Arguments to format string
 	LoggerName  string        // Name of the logger module
 	Level       level         // Level of the record
-	Time        time.Time     // Time of the record (local time)
+	Time        time.Time     // Time of the record (local time unless the logger sets a time zone)
 	Filename    string        // File name of the log call (absolute path)
 	Line        int           // Lint number in file
+	Function    string        // Function name of the caller
 	ProcessID   int           // PID
 	ProcessName string        // Name of the process
+	Hostname    string        // Name of the host, empty if it can not be resolved
+	Fields      Fields        // Structured fields of the record
+	GoroutineID uint64        // ID of the logging goroutine, zero unless enabled
+	Stack       string        // Stack trace of the logging goroutine, empty unless enabled
+}
+
+// Fields holds structured key value pairs attached to a record. Fields passed
+// as the last argument of a log call are attached to the record instead of
+// being formatted:
+//
+//	l.Info("user %s logged in", name, logger.Fields{"ip": ip})
+type Fields map[string]interface{}
+
+// Message returns the log message of the record, formatted in the manner of
+// fmt.Printf and without the trailing newline.
+func (rec *Record) Message() string {
+	return strings.TrimSuffix(interpolate(rec.Format, rec.Args), "\n")
+}
+
+// truncateMessage returns message cut to MaxMessageLength bytes, on a rune
+// boundary, followed by the number of bytes cut. The trailing newline of the
+// message is not counted.
+func truncateMessage(message string) string {
+	trimmed := strings.TrimSuffix(message, "\n")
+	if MaxMessageLength <= 0 || len(trimmed) <= MaxMessageLength {
+		return message
+	}
+	message = trimmed
+	n := MaxMessageLength
+	for n > 0 && !utf8.RuneStart(message[n]) {
+		n--
+	}
+	return message[:n] + "…(truncated " + strconv.Itoa(len(message)-n) + " bytes)"
 }
 
 // Formatter formats a record.
+//
+// Formatters return the record without a trailing newline: handlers writing
+// line oriented output terminate it with exactly one.
 type Formatter interface {
 	// Format the record and return a message.
 	Format(*Record) (message string)
@@ -160,16 +409,147 @@ type Formatter interface {
 //                   //
 // /////////////////////
 
-type defaultFormatter struct {
+// textTimeLayout is the time layout of TextFormatter.
+const textTimeLayout = "2006-01-02 15:04:05"
+
+// TimePrecision is the number of fractional second digits of the time
+// rendered by the text formatters.
+type TimePrecision int
+
+const (
+	Seconds      TimePrecision = 0
+	Milliseconds TimePrecision = 3
+	Microseconds TimePrecision = 6
+	Nanoseconds  TimePrecision = 9
+)
+
+// layout returns the time layout of TextFormatter with the precision.
+func (p TimePrecision) layout() string {
+	if p <= Seconds {
+		return textTimeLayout
+	}
+	if p > Nanoseconds {
+		p = Nanoseconds
+	}
+	return textTimeLayout + "." + strings.Repeat("0", int(p))
 }
 
-func (df *defaultFormatter) Format(rec *Record) string {
-	paths := strings.Split(rec.Filename, string(os.PathSeparator))
+// MultilineMode selects how the text formatters output the newlines inside
+// a record, i.e. in its message or stack.
+type MultilineMode int
 
-	filePath := strings.Join(paths[len(paths)-2:], string(os.PathSeparator))
+const (
+	// MultilineKeep outputs the newlines as is.
+	MultilineKeep MultilineMode = iota
 
-	return fmt.Sprintf("%s %-8s[%s:%d] %s", fmt.Sprint(rec.Time)[:19],
-		levelNames[rec.Level], filePath, rec.Line, fmt.Sprintf(rec.Format, rec.Args...))
+	// MultilineEscape replaces the newlines with a literal \n, keeping each
+	// record on a single line.
+	MultilineEscape
+
+	// MultilineIndent indents the continuation lines with a tab, so line
+	// oriented parsers can tell them from the start of a record.
+	MultilineIndent
+)
+
+// apply returns s with its newlines output in mode m.
+func (m MultilineMode) apply(s string) string {
+	switch m {
+	case MultilineEscape:
+		return strings.Replace(s, "\n", `\n`, -1)
+	case MultilineIndent:
+		return strings.Replace(s, "\n", "\n\t", -1)
+	}
+	return s
+}
+
+// timeLayout returns the time layout of the text formatters.
+func timeLayout(layout string, p TimePrecision) string {
+	if layout != "" {
+		return layout
+	}
+	return p.layout()
+}
+
+// TextFormatter is the default Formatter. It formats records as a single
+// line of text containing the time, level, caller and message, followed by
+// the structured fields as logfmt key=value pairs.
+type TextFormatter struct {
+	// ShowProcess adds the process name and PID to the output.
+	ShowProcess bool
+
+	// TimePrecision sets the fractional second digits of the time. Default
+	// is Seconds.
+	TimePrecision TimePrecision
+
+	// TimeLayout overrides the layout of the time, e.g. time.RFC3339Nano.
+	// TimePrecision is ignored when it is set.
+	TimeLayout string
+
+	// Multiline selects how the newlines inside a record are output.
+	// Default is MultilineKeep.
+	Multiline MultilineMode
+
+	// TrimPath shortens the caller file name. DefaultTrimPath is used when
+	// it is nil.
+	TrimPath func(string) string
+}
+
+func (f *TextFormatter) Format(rec *Record) string {
+	return f.format(rec, fmt.Sprintf("%-8s", rec.Level))
+}
+
+// format formats the record with the given, already padded, level name.
+func (f *TextFormatter) format(rec *Record, levelName string) string {
+	var process string
+	if f.ShowProcess {
+		process = fmt.Sprintf("[%s:%d]", rec.ProcessName, rec.ProcessID)
+	}
+	if rec.GoroutineID != 0 {
+		process += fmt.Sprintf("[goroutine:%d]", rec.GoroutineID)
+	}
+
+	s := fmt.Sprintf("%s %s%s[%s:%d] %s", rec.Time.Format(timeLayout(f.TimeLayout, f.TimePrecision)),
+		levelName, process, trimPath(f.TrimPath, rec.Filename), rec.Line, truncateMessage(interpolate(rec.Format, rec.Args)))
+	if len(rec.Fields) > 0 {
+		s = string(appendFields([]byte(strings.TrimRight(s, "\n")), rec.Fields))
+	}
+	if rec.Stack != "" {
+		s = line(s) + rec.Stack
+	}
+	return f.Multiline.apply(strings.TrimRight(s, "\n"))
+}
+
+// shortPath returns the last two elements of the file path,
+// which are the package directory and the file name.
+func shortPath(filename string) string {
+	paths := strings.Split(filename, string(os.PathSeparator))
+	if len(paths) < 2 {
+		return filename
+	}
+	return strings.Join(paths[len(paths)-2:], string(os.PathSeparator))
+}
+
+// TrimPathPrefix returns a function trimming prefix, e.g. the module root,
+// from the file paths under it. Other paths are shortened to their package
+// directory and file name.
+func TrimPathPrefix(prefix string) func(string) string {
+	prefix = strings.TrimRight(prefix, `/\`)
+	return func(filename string) string {
+		rest := strings.TrimPrefix(filename, prefix)
+		if rest != filename && rest != "" && (rest[0] == '/' || rest[0] == '\\') {
+			return rest[1:]
+		}
+		return shortPath(filename)
+	}
+}
+
+// trimPath returns filename shortened by trim, or by DefaultTrimPath if trim
+// is nil.
+func trimPath(trim func(string) string, filename string) string {
+	if trim == nil {
+		trim = DefaultTrimPath
+	}
+	return trim(filename)
 }
 
 // /////////////////////////
@@ -180,27 +560,73 @@ func (df *defaultFormatter) Format(rec *Record) string {
 
 // logger is the default Logger implementation.
 type logger struct {
-	Name      string
-	Level     level
+	name      string
 	Handler   Handler
+	lvl       int32 // lvl holds the level, accessed atomically to allow changes while logging
 	calldepth int
+	location  *time.Location // location of the record times, nil for local time
+	goid      bool           // goid enables recording goroutine IDs
+	stack     bool           // stack enables capturing stack traces
+	stackMin  level          // stackMin is the least severe level with stack traces
+	errorHook func(error)    // errorHook receives handler errors, nil for the default
 }
 
 func NewLogger(name string) Logger {
+	lv, ok := moduleLevel(name)
+	if !ok {
+		lv = DefaultLevel
+	}
 	return &logger{
-		Name:    name,
-		Level:   DefaultLevel,
+		name:    name,
 		Handler: DefaultHandler,
+		lvl:     int32(lv),
 	}
 }
 
+func (l *logger) Name() string {
+	return l.name
+}
+
 // New creates a new inerhited logger with the given prefixes.
 func (l *logger) New(prefixes ...interface{}) Logger {
-	return newContext(*l, "", prefixes...)
+	return newContext(l.clone(), "", prefixes...)
+}
+
+func (l *logger) WithPrefix(prefix string) Logger {
+	return withPrefix(l.clone(), "", prefix)
+}
+
+// clone returns a copy of the logger. It is built field by field to read the
+// level atomically, as it may change concurrently.
+func (l *logger) clone() logger {
+	return logger{
+		name:      l.name,
+		Handler:   l.Handler,
+		lvl:       atomic.LoadInt32(&l.lvl),
+		calldepth: l.calldepth,
+		location:  l.location,
+		goid:      l.goid,
+		stack:     l.stack,
+		stackMin:  l.stackMin,
+		errorHook: l.errorHook,
+	}
+}
+
+func (l *logger) Writer(lv level) io.Writer {
+	return &lineWriter{logger: l, level: lv}
 }
 
 func (l *logger) SetLevel(level level) {
-	l.Level = level
+	atomic.StoreInt32(&l.lvl, int32(level))
+}
+
+func (l *logger) IsEnabled(lv level) bool {
+	return l.currentLevel() >= lv
+}
+
+// currentLevel returns the level of the logger.
+func (l *logger) currentLevel() level {
+	return level(atomic.LoadInt32(&l.lvl))
 }
 
 func (l *logger) SetHandler(b Handler) {
@@ -211,86 +637,381 @@ func (l *logger) SetCallDepth(d int) {
 	l.calldepth = d
 }
 
-// Fatal is equivalent to l.Critical followed by a call to os.Exit(1).
+func (l *logger) SetTimeZone(loc *time.Location) {
+	l.location = loc
+}
+
+func (l *logger) SetGoroutineID(enabled bool) {
+	l.goid = enabled
+}
+
+func (l *logger) SetStackTrace(enabled bool, min level) {
+	l.stack = enabled
+	l.stackMin = min
+}
+
+func (l *logger) SetErrorHook(hook func(error)) {
+	l.errorHook = hook
+}
+
+// Fatal is equivalent to l.Critical followed by a call to os.Exit(1). The
+// handler of l is closed before exiting, as with Close.
 func (l *logger) Fatal(format string, args ...interface{}) {
+	l.FatalWithCode(1, format, args...)
+}
+
+// FatalWithCode is equivalent to l.Critical followed by a call to
+// os.Exit(code). The handler of l is closed before exiting, as with Close.
+func (l *logger) FatalWithCode(code int, format string, args ...interface{}) {
 	l.Critical(format, args...)
-	l.Handler.Close()
-	os.Exit(1)
+	if err := shutdown(l.Handler); err != nil {
+		l.reportError(err)
+	}
+	exitFunc(code)
 }
 
-// Panic is equivalent to Critical() followed by a call to panic().
+// Panic is equivalent to Critical() followed by a call to panic(). The
+// handler is flushed first, since the panic may end the program.
 func (l *logger) Panic(format string, args ...interface{}) {
+	// Lazy arguments are computed once, for both the record and the panic.
+	args = resolveArgs(args)
 	l.Critical(format, args...)
-	panic(fmt.Sprintf(format, args...))
+	if err := l.Handler.Flush(); err != nil {
+		l.reportError(err)
+	}
+	args, _ = splitFields(args, nil)
+	panicFunc(fmt.Sprintf(format, args...))
 }
 
 // Critical sends a critical level log message to the handler. Arguments are handled in the manner of fmt.Printf.
 func (l *logger) Critical(format string, args ...interface{}) {
-	if l.Level >= CRITICAL {
-		l.log(CRITICAL, format, args...)
+	if l.currentLevel() >= CRITICAL {
+		l.log(CRITICAL, nil, format, args...)
 	}
 }
 
 // Error sends a error level log message to the handler. Arguments are handled in the manner of fmt.Printf.
 func (l *logger) Error(format string, args ...interface{}) {
-	if l.Level >= ERROR {
-		l.log(ERROR, format, args...)
+	if l.currentLevel() >= ERROR {
+		l.log(ERROR, nil, format, args...)
 	}
 }
 
 // Warning sends a warning level log message to the handler. Arguments are handled in the manner of fmt.Printf.
 func (l *logger) Warning(format string, args ...interface{}) {
-	if l.Level >= WARNING {
-		l.log(WARNING, format, args...)
+	if l.currentLevel() >= WARNING {
+		l.log(WARNING, nil, format, args...)
 	}
 }
 
 // Notice sends a notice level log message to the handler. Arguments are handled in the manner of fmt.Printf.
 func (l *logger) Notice(format string, args ...interface{}) {
-	if l.Level >= NOTICE {
-		l.log(NOTICE, format, args...)
+	if l.currentLevel() >= NOTICE {
+		l.log(NOTICE, nil, format, args...)
 	}
 }
 
 // Info sends a info level log message to the handler. Arguments are handled in the manner of fmt.Printf.
 func (l *logger) Info(format string, args ...interface{}) {
-	if l.Level >= INFO {
-		l.log(INFO, format, args...)
+	if l.currentLevel() >= INFO {
+		l.log(INFO, nil, format, args...)
 	}
 }
 
 // Debug sends a debug level log message to the handler. Arguments are handled in the manner of fmt.Printf.
 func (l *logger) Debug(format string, args ...interface{}) {
-	if l.Level >= DEBUG {
-		l.log(DEBUG, format, args...)
+	if l.currentLevel() >= DEBUG {
+		l.log(DEBUG, nil, format, args...)
+	}
+}
+
+// Log sends a log message with the given level to the handler. Arguments are handled in the manner of fmt.Printf.
+func (l *logger) Log(level level, format string, args ...interface{}) {
+	if l.currentLevel() >= level {
+		l.log(level, nil, format, args...)
 	}
 }
 
-func (l *logger) log(level level, format string, args ...interface{}) {
+// CriticalCtx sends a critical level log message with the fields of ctx to the handler. Arguments are handled in
+// the manner of fmt.Printf.
+func (l *logger) CriticalCtx(ctx gocontext.Context, format string, args ...interface{}) {
+	l.LogCtx(ctx, CRITICAL, format, args...)
+}
+
+// ErrorCtx sends a error level log message with the fields of ctx to the handler. Arguments are handled in
+// the manner of fmt.Printf.
+func (l *logger) ErrorCtx(ctx gocontext.Context, format string, args ...interface{}) {
+	l.LogCtx(ctx, ERROR, format, args...)
+}
+
+// WarningCtx sends a warning level log message with the fields of ctx to the handler. Arguments are handled in
+// the manner of fmt.Printf.
+func (l *logger) WarningCtx(ctx gocontext.Context, format string, args ...interface{}) {
+	l.LogCtx(ctx, WARNING, format, args...)
+}
+
+// NoticeCtx sends a notice level log message with the fields of ctx to the handler. Arguments are handled in
+// the manner of fmt.Printf.
+func (l *logger) NoticeCtx(ctx gocontext.Context, format string, args ...interface{}) {
+	l.LogCtx(ctx, NOTICE, format, args...)
+}
+
+// InfoCtx sends a info level log message with the fields of ctx to the handler. Arguments are handled in
+// the manner of fmt.Printf.
+func (l *logger) InfoCtx(ctx gocontext.Context, format string, args ...interface{}) {
+	l.LogCtx(ctx, INFO, format, args...)
+}
+
+// DebugCtx sends a debug level log message with the fields of ctx to the handler. Arguments are handled in
+// the manner of fmt.Printf.
+func (l *logger) DebugCtx(ctx gocontext.Context, format string, args ...interface{}) {
+	l.LogCtx(ctx, DEBUG, format, args...)
+}
+
+// LogCtx sends a log message with the given level and the fields of ctx to the handler. Arguments are handled in
+// the manner of fmt.Printf.
+func (l *logger) LogCtx(ctx gocontext.Context, level level, format string, args ...interface{}) {
+	if l.currentLevel() >= level {
+		l.log(level, contextFields(ctx), format, args...)
+	}
+}
+
+func (l *logger) log(level level, fields Fields, format string, args ...interface{}) {
+	args, fields = splitFields(args, fields)
+
 	// Add missing newline at the end.
 	if !strings.HasSuffix(format, "\n") {
 		format += "\n"
 	}
 
-	_, file, line, ok := runtime.Caller(l.calldepth + 3)
-	if !ok {
-		file = "???"
-		line = 0
+	// Caller fields are left empty when the stack can not be resolved.
+	frame, _ := caller(l.calldepth)
+
+	t := now()
+	if l.location != nil {
+		t = t.In(l.location)
 	}
 
-	rec := &Record{
+	rec := recordPool.Get().(*Record)
+	*rec = Record{
 		Format:      format,
-		Args:        args,
-		LoggerName:  l.Name,
+		Args:        resolveArgs(args),
+		LoggerName:  l.name,
 		Level:       level,
-		Time:        time.Now(),
-		Filename:    file,
-		Line:        line,
-		ProcessID:   os.Getpid(),
-		ProcessName: procName(),
+		Time:        t,
+		Filename:    frame.File,
+		Line:        frame.Line,
+		Function:    funcName(frame.Function),
+		ProcessID:   pid,
+		ProcessName: pname,
+		Hostname:    hostname,
+		Fields:      fields,
+	}
+
+	if l.goid {
+		rec.GoroutineID = goroutineID()
+	}
+	if l.stack && level <= l.stackMin {
+		rec.Stack = stack()
+	}
+
+	if err := handle(l.Handler, rec); err != nil {
+		l.reportError(err)
+	}
+
+	*rec = Record{}
+	recordPool.Put(rec)
+}
+
+// reportError passes err to the error hook of the logger.
+func (l *logger) reportError(err error) {
+	if l.errorHook != nil {
+		l.errorHook(err)
+	} else {
+		defaultErrorHook(err)
+	}
+}
+
+// resolveArgs returns args with the func() interface{} values replaced by
+// their results. Such arguments are only computed for records which are
+// logged. args is left untouched.
+func resolveArgs(args []interface{}) []interface{} {
+	var resolved []interface{}
+	for i, arg := range args {
+		fn, ok := arg.(func() interface{})
+		if !ok {
+			continue
+		}
+		if resolved == nil {
+			resolved = make([]interface{}, len(args))
+			copy(resolved, args)
+		}
+		resolved[i] = fn()
+	}
+	if resolved == nil {
+		return args
+	}
+	return resolved
+}
+
+// splitFields removes a final Fields argument from args and merges it into
+// fields, its values taking precedence. fields is left untouched.
+func splitFields(args []interface{}, fields Fields) ([]interface{}, Fields) {
+	if len(args) == 0 {
+		return args, fields
+	}
+	extra, ok := args[len(args)-1].(Fields)
+	if !ok {
+		return args, fields
+	}
+	args = args[:len(args)-1]
+
+	if len(fields) == 0 {
+		return args, extra
+	}
+	merged := make(Fields, len(fields)+len(extra))
+	for k, v := range fields {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return args, merged
+}
+
+// handle passes rec to h, turning a panic of h into an error.
+func handle(h Handler, rec *Record) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%T panicked: %v", h, r)
+		}
+	}()
+	return h.Handle(rec)
+}
+
+// defaultErrorHook prints handler errors to stderr.
+func defaultErrorHook(err error) {
+	fmt.Fprintf(os.Stderr, "logger: %s\n", err)
+}
+
+// recordPool recycles the records of log calls.
+var recordPool = sync.Pool{
+	New: func() interface{} { return new(Record) },
+}
+
+// pkgPath is the import path of this package, used to recognize its frames
+// on the call stack.
+var pkgPath = reflect.TypeOf(logger{}).PkgPath()
+
+// caller returns the file name and line number of the first function outside
+// of this package on the call stack, skipping calldepth more frames above it.
+// Frames of this package's tests are treated as outside callers.
+func caller(calldepth int) (frame runtime.Frame, ok bool) {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	found := false
+	for {
+		f, more := frames.Next()
+		if !found && !isInternalFrame(f) {
+			found = true
+		}
+		if found {
+			if calldepth == 0 {
+				return f, true
+			}
+			calldepth--
+		}
+		if !more {
+			return runtime.Frame{}, false
+		}
+	}
+}
+
+// isInternalFrame reports whether the frame belongs to this package, or to
+// the standard library's log package writing through StdlibWriter.
+func isInternalFrame(frame runtime.Frame) bool {
+	if strings.HasPrefix(frame.Function, "log.") {
+		return true
+	}
+	return strings.HasPrefix(frame.Function, pkgPath+".") &&
+		!strings.HasSuffix(frame.File, "_test.go")
+}
+
+// funcName returns the function name in the form selected by
+// FullFunctionNames.
+func funcName(name string) string {
+	if FullFunctionNames {
+		return name
+	}
+	return shortFuncName(name)
+}
+
+// shortFuncName trims the package path from a fully qualified function name,
+// turning "github.com/user/pkg.(*T).Method" into "pkg.(*T).Method".
+func shortFuncName(name string) string {
+	i := strings.LastIndexByte(name, '/')
+	if i < 0 {
+		return name
+	}
+	short := name[i+1:]
+
+	// The major version suffix of "github.com/user/pkg/v2.Func" is not the
+	// package name, the element before it is.
+	if j := strings.IndexByte(short, '.'); j > 0 && isMajorVersion(short[:j]) {
+		return shortFuncName(name[:i]) + short[j:]
+	}
+	return short
+}
+
+// isMajorVersion reports whether the import path element is a major version
+// suffix such as "v2".
+func isMajorVersion(elem string) bool {
+	if len(elem) < 2 || elem[0] != 'v' {
+		return false
 	}
+	for _, c := range elem[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// goroutineID returns the ID of the current goroutine, parsed from the
+// "goroutine N [status]:" header of its stack trace.
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
+
+// stack returns the stack trace of the current goroutine.
+func stack() string {
+	buf := make([]byte, 4096)
+	for {
+		n := runtime.Stack(buf, false)
+		if n < len(buf) {
+			return string(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
 
-	l.Handler.Handle(rec)
+// hostName returns the host name reported by the kernel, or an empty string
+// if it can not be resolved.
+func hostName() string {
+	name, err := os.Hostname()
+	if err != nil {
+		return ""
+	}
+	return name
 }
 
 // procName returns the name of the current process.
@@ -304,11 +1025,46 @@ func procName() string {
 //               //
 // /////////////////
 
+// SetLevel changes the level of the DefaultLogger.
+func SetLevel(l level) {
+	DefaultLogger.SetLevel(l)
+}
+
+// IsEnabled reports whether the DefaultLogger logs messages of the given level.
+func IsEnabled(l level) bool {
+	return DefaultLogger.IsEnabled(l)
+}
+
+// SetHandler replaces the handler of the DefaultLogger.
+func SetHandler(h Handler) {
+	DefaultLogger.SetHandler(h)
+}
+
+// SetErrorHook sets the function receiving the handler errors of the DefaultLogger.
+func SetErrorHook(hook func(error)) {
+	DefaultLogger.SetErrorHook(hook)
+}
+
+// SetFormatter changes the formatter of the DefaultLogger's handler.
+func SetFormatter(f Formatter) {
+	switch l := DefaultLogger.(type) {
+	case *logger:
+		l.Handler.SetFormatter(f)
+	case *context:
+		l.Handler.SetFormatter(f)
+	}
+}
+
 // Fatal is equivalent to Critical() followed by a call to os.Exit(1).
 func Fatal(format string, args ...interface{}) {
 	DefaultLogger.Fatal(format, args...)
 }
 
+// FatalWithCode is equivalent to Critical() followed by a call to os.Exit(code).
+func FatalWithCode(code int, format string, args ...interface{}) {
+	DefaultLogger.FatalWithCode(code, format, args...)
+}
+
 // Panic is equivalent to Critical() followed by a call to panic().
 func Panic(format string, args ...interface{}) {
 	DefaultLogger.Panic(format, args...)
@@ -344,6 +1100,17 @@ func Debug(format string, args ...interface{}) {
 	DefaultLogger.Debug(format, args...)
 }
 
+// Log prints a log message with the given level to the stderr. Arguments are handled in the manner of fmt.Printf.
+func Log(level level, format string, args ...interface{}) {
+	DefaultLogger.Log(level, format, args...)
+}
+
+// LogCtx prints a log message with the given level and the fields of ctx to the stderr. Arguments are handled
+// in the manner of fmt.Printf.
+func LogCtx(ctx gocontext.Context, level level, format string, args ...interface{}) {
+	DefaultLogger.LogCtx(ctx, level, format, args...)
+}
+
 // ///////////////
 //             //
 // BaseHandler //
@@ -369,17 +1136,29 @@ func (h *BaseHandler) SetLevel(l level) {
 	h.Level = l
 }
 
-// SetFormatter sets logger formatter for handler
+// SetFormatter sets logger formatter for handler. A nil formatter is
+// ignored, keeping the current one.
 func (h *BaseHandler) SetFormatter(f Formatter) {
-	h.Formatter = f
+	if f != nil {
+		h.Formatter = f
+	}
+}
+
+// Flush does nothing, the records are not buffered.
+func (h *BaseHandler) Flush() error {
+	return nil
 }
 
-// FilterAndFormat filters any record according to logger level
+// FilterAndFormat filters any record according to logger level. Records are
+// formatted with DefaultFormatter if the formatter is nil.
 func (h *BaseHandler) FilterAndFormat(rec *Record) string {
-	if h.Level >= rec.Level {
-		return h.Formatter.Format(rec)
+	if h.Level < rec.Level {
+		return ""
+	}
+	if h.Formatter == nil {
+		return DefaultFormatter.Format(rec)
 	}
-	return ""
+	return h.Formatter.Format(rec)
 }
 
 // /////////////////
@@ -389,8 +1168,11 @@ func (h *BaseHandler) FilterAndFormat(rec *Record) string {
 // /////////////////
 
 // WriterHandler is a handler implementation that writes the logger output to a io.Writer.
+// Each record is written with a single call terminated by a newline, writes
+// are serialized so the writer needs not be safe for concurrent use.
 type WriterHandler struct {
 	*BaseHandler
+	mu       sync.Mutex
 	w        io.Writer
 	Colorize bool
 }
@@ -403,22 +1185,45 @@ func NewWriterHandler(w io.Writer) *WriterHandler {
 	}
 }
 
-func (b *WriterHandler) Handle(rec *Record) {
+func (b *WriterHandler) Handle(rec *Record) error {
 	message := b.BaseHandler.FilterAndFormat(rec)
 	if message == "" {
-		return
+		return nil
 	}
 	if b.Colorize {
-		b.w.Write([]byte(fmt.Sprintf("\033[%dm", levelColors[rec.Level])))
+		// Reset the color before the newline to keep it off the next line.
+		message = colorize(levelColor(rec.Level), strings.TrimRight(message, "\n"))
 	}
-	fmt.Fprint(b.w, message)
-	if b.Colorize {
-		b.w.Write([]byte("\033[0m")) // reset color
+	message = line(message)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, err := io.WriteString(b.w, message)
+	return err
+}
+
+// Flush flushes the writer if it is buffered, e.g. a *bufio.Writer.
+func (b *WriterHandler) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if f, ok := b.w.(interface{ Flush() error }); ok {
+		return f.Flush()
 	}
+	return nil
 }
 
-// Close closes WriterHandler
-func (b *WriterHandler) Close() {}
+// Close closes the writer if it is an io.Closer other than os.Stdout and
+// os.Stderr. Other writers are left to the caller to close.
+func (b *WriterHandler) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.w == os.Stdout || b.w == os.Stderr {
+		return
+	}
+	if c, ok := b.w.(io.Closer); ok {
+		c.Close()
+	}
+}
 
 // ////////////////
 //              //
@@ -431,9 +1236,16 @@ type MultiHandler struct {
 	handlers []Handler
 }
 
-// NewMultiHandler creates a new handler with given handlers
+// NewMultiHandler creates a new handler with given handlers. Nil handlers
+// are ignored.
 func NewMultiHandler(handlers ...Handler) *MultiHandler {
-	return &MultiHandler{handlers: handlers}
+	b := &MultiHandler{}
+	for _, h := range handlers {
+		if h != nil {
+			b.handlers = append(b.handlers, h)
+		}
+	}
+	return b
 }
 
 // SetFormatter sets formatter for all handlers
@@ -450,17 +1262,38 @@ func (b *MultiHandler) SetLevel(l level) {
 	}
 }
 
-// Handle handles given record with all handlers concurrently
-func (b *MultiHandler) Handle(rec *Record) {
+// Handle handles given record with all handlers concurrently. The errors of
+// the handlers are combined.
+func (b *MultiHandler) Handle(rec *Record) error {
+	errs := make([]error, len(b.handlers))
 	wg := sync.WaitGroup{}
 	wg.Add(len(b.handlers))
-	for _, handler := range b.handlers {
-		go func(handler Handler) {
-			handler.Handle(rec)
+	for i, handler := range b.handlers {
+		go func(i int, handler Handler) {
+			errs[i] = handle(handler, rec)
 			wg.Done()
-		}(handler)
+		}(i, handler)
 	}
 	wg.Wait()
+
+	return multiError(errs).err()
+}
+
+// Flush flushes all handlers concurrently. The errors of the handlers are
+// combined.
+func (b *MultiHandler) Flush() error {
+	errs := make([]error, len(b.handlers))
+	wg := sync.WaitGroup{}
+	wg.Add(len(b.handlers))
+	for i, handler := range b.handlers {
+		go func(i int, handler Handler) {
+			errs[i] = handler.Flush()
+			wg.Done()
+		}(i, handler)
+	}
+	wg.Wait()
+
+	return multiError(errs).err()
 }
 
 // Close closes all handlers concurrently
@@ -475,3 +1308,37 @@ func (b *MultiHandler) Close() {
 	}
 	wg.Wait()
 }
+
+// multiError combines the errors of several handlers.
+type multiError []error
+
+func (e multiError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// add appends err unless it is nil.
+func (e *multiError) add(err error) {
+	if err != nil {
+		*e = append(*e, err)
+	}
+}
+
+// err returns nil when there are no errors, the error itself when there is
+// a single one and the combined errors otherwise. Nil errors are skipped.
+func (e multiError) err() error {
+	var errs multiError
+	for _, err := range e {
+		errs.add(err)
+	}
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return errs
+}
@@ -0,0 +1,1156 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	gocontext "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLogger_Levels(t *testing.T) {
+	r := NewLogRecorder()
+	l := NewLogger("levels")
+	l.SetLevel(DEBUG)
+	l.SetHandler(r)
+
+	tests := []struct {
+		level level
+		fn    func(format string, args ...interface{})
+	}{
+		{CRITICAL, l.Critical},
+		{ERROR, l.Error},
+		{WARNING, l.Warning},
+		{NOTICE, l.Notice},
+		{INFO, l.Info},
+		{DEBUG, l.Debug},
+	}
+
+	for i, test := range tests {
+		test.fn("message %d", i)
+	}
+
+	recs := r.Records["levels"]
+	if len(recs) != len(tests) {
+		t.Fatalf("expected %d records got %d", len(tests), len(recs))
+	}
+	for i, test := range tests {
+		rec := recs[i]
+		if rec.Level != test.level {
+			t.Errorf("expected level %s got %s", levelNames[test.level], levelNames[rec.Level])
+		}
+		if msg := fmt.Sprintf(rec.Format, rec.Args...); msg != fmt.Sprintf("message %d\n", i) {
+			t.Errorf("unexpected message %q", msg)
+		}
+		if rec.Time.IsZero() {
+			t.Errorf("record time is not set")
+		}
+	}
+}
+
+func TestLogger_LevelFilter(t *testing.T) {
+	r := NewLogRecorder()
+	l := NewLogger("filter")
+	l.SetLevel(WARNING)
+	l.SetHandler(r)
+
+	l.Debug("debug")
+	l.Info("info")
+	l.Warning("warning")
+	l.Error("error")
+
+	if n := len(r.Records["filter"]); n != 2 {
+		t.Errorf("expected 2 records got %d", n)
+	}
+}
+
+func TestLogger_Panic(t *testing.T) {
+	r := NewLogRecorder()
+	l := NewLogger("panic")
+	l.SetHandler(r)
+
+	defer func() {
+		v := recover()
+		if v != "boom 42" {
+			t.Errorf("unexpected panic value %v", v)
+		}
+		recs := r.Records["panic"]
+		if len(recs) != 1 || recs[0].Level != CRITICAL {
+			t.Fatalf("expected one critical record got %v", recs)
+		}
+		if !strings.HasPrefix(recs[0].Format, "boom") {
+			t.Errorf("unexpected format %q", recs[0].Format)
+		}
+		if r.Flushed != 1 {
+			t.Errorf("expected the handler to be flushed before panicking")
+		}
+	}()
+
+	l.Panic("boom %d", 42)
+}
+
+func TestWriterHandler_Handle(t *testing.T) {
+	var buf strings.Builder
+	h := NewWriterHandler(&buf)
+	h.SetLevel(WARNING)
+
+	l := NewLogger("writer")
+	l.SetLevel(DEBUG)
+	l.SetHandler(h)
+
+	l.Info("filtered")
+	l.Warning("kept %d", 1)
+
+	out := buf.String()
+	if strings.Contains(out, "filtered") {
+		t.Errorf("record below handler level was written: %q", out)
+	}
+	if !strings.Contains(out, "WARNING") || !strings.HasSuffix(out, "kept 1\n") {
+		t.Errorf("unexpected output %q", out)
+	}
+}
+
+// closingBuffer is a bytes.Buffer recording whether it is closed.
+type closingBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *closingBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestWriterHandler_Buffer(t *testing.T) {
+	var buf closingBuffer
+	h := NewWriterHandler(&buf)
+	h.SetFormatter(&messageFormatter{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			h.Handle(&Record{Format: "record %d", Args: []interface{}{i}, Level: INFO})
+		}(i)
+	}
+	wg.Wait()
+
+	lines := strings.Split(buf.String(), "\n")
+	if len(lines) != 11 || lines[10] != "" {
+		t.Fatalf("expected 10 newline terminated records got %q", buf.String())
+	}
+	for _, line := range lines[:10] {
+		if !strings.HasPrefix(line, "record ") {
+			t.Errorf("unexpected line %q", line)
+		}
+	}
+
+	h.Close()
+	if !buf.closed {
+		t.Errorf("expected the writer to be closed")
+	}
+}
+
+func TestWriterHandler_Flush(t *testing.T) {
+	var buf strings.Builder
+	w := bufio.NewWriter(&buf)
+	h := NewWriterHandler(w)
+
+	h.Handle(&Record{Format: "buffered\n", Level: INFO})
+	if buf.Len() != 0 {
+		t.Fatalf("expected the record to be buffered got %q", buf.String())
+	}
+	if err := h.Flush(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if out := buf.String(); !strings.HasSuffix(out, "buffered\n") {
+		t.Errorf("unexpected output %q", out)
+	}
+}
+
+func TestWriterHandler_Colorize(t *testing.T) {
+	var buf strings.Builder
+	h := NewWriterHandler(&buf)
+	h.Colorize = true
+
+	l := NewLogger("color")
+	l.SetHandler(h)
+	l.Error("colored")
+
+	out := buf.String()
+	if !strings.HasPrefix(out, "\033[31m") || !strings.HasSuffix(out, "\033[0m\n") {
+		t.Errorf("expected colored output got %q", out)
+	}
+}
+
+type wrapper struct {
+	Logger
+}
+
+func (w wrapper) Info(format string, args ...interface{}) {
+	w.Logger.Info(format, args...)
+}
+
+func TestLogger_SetCallDepth(t *testing.T) {
+	r := NewLogRecorder()
+	l := NewLogger("calldepth")
+	l.SetHandler(r)
+	l.SetCallDepth(1)
+
+	w := wrapper{l}
+	_, _, line, _ := runtime.Caller(0)
+	w.Info("wrapped") // must be reported at line+1
+
+	recs := r.Records["calldepth"]
+	if len(recs) != 1 {
+		t.Fatalf("expected 1 record got %d", len(recs))
+	}
+	if !strings.HasSuffix(recs[0].Filename, "logger_test.go") || recs[0].Line != line+1 {
+		t.Errorf("expected logger_test.go:%d got %s:%d", line+1, recs[0].Filename, recs[0].Line)
+	}
+}
+
+func TestLogger_Caller(t *testing.T) {
+	r := NewLogRecorder()
+	l := NewLogger("caller")
+	l.SetHandler(r)
+
+	_, _, line, _ := runtime.Caller(0)
+	l.Info("caller") // must be reported at line+1
+
+	rec := r.Records["caller"][0]
+	if !strings.HasSuffix(rec.Filename, "logger_test.go") {
+		t.Errorf("unexpected file name %q", rec.Filename)
+	}
+	if rec.Line != line+1 {
+		t.Errorf("expected line %d got %d", line+1, rec.Line)
+	}
+}
+
+func TestFormatter_EmptyCaller(t *testing.T) {
+	rec := &Record{Format: "no caller\n", Level: INFO, Time: time.Now()}
+
+	for _, f := range []Formatter{DefaultFormatter, &CustomFormatter{}} {
+		if out := f.Format(rec); !strings.Contains(out, "[:0]") {
+			t.Errorf("unexpected output %q", out)
+		}
+	}
+}
+
+func TestLogger_Process(t *testing.T) {
+	r := NewLogRecorder()
+	l := NewLogger("process")
+	l.SetHandler(r)
+	l.Info("process")
+
+	rec := r.Records["process"][0]
+	if rec.ProcessID != os.Getpid() {
+		t.Errorf("expected pid %d got %d", os.Getpid(), rec.ProcessID)
+	}
+	if rec.ProcessName == "" {
+		t.Errorf("process name is empty")
+	}
+
+	f := &TextFormatter{ShowProcess: true}
+	if out := f.Format(rec); !strings.Contains(out, fmt.Sprintf("[%s:%d]", rec.ProcessName, rec.ProcessID)) {
+		t.Errorf("process is missing from output %q", out)
+	}
+}
+
+func TestMultiHandler(t *testing.T) {
+	r1, r2 := NewLogRecorder(), NewLogRecorder()
+	h := NewMultiHandler(r1, r2)
+
+	l := NewLogger("multi")
+	l.SetHandler(h)
+	for i := 0; i < 10; i++ {
+		l.Info("record %d", i)
+	}
+
+	h.SetLevel(ERROR)
+	h.SetFormatter(&JSONFormatter{})
+	h.Close()
+
+	for _, r := range []*LogRecorder{r1, r2} {
+		if n := len(r.Records["multi"]); n != 10 {
+			t.Errorf("expected 10 records got %d", n)
+		}
+		if r.Level != ERROR {
+			t.Errorf("level is not propagated")
+		}
+		if _, ok := r.Formatter.(*JSONFormatter); !ok {
+			t.Errorf("formatter is not propagated")
+		}
+		if !r.Closed {
+			t.Errorf("handler is not closed")
+		}
+	}
+}
+
+func TestHandler_NilFormatter(t *testing.T) {
+	var buf strings.Builder
+	h := NewWriterHandler(&buf)
+	h.SetFormatter(&LogfmtFormatter{})
+	h.SetFormatter(nil)
+
+	l := NewLogger("nilformatter")
+	l.SetHandler(h)
+	l.Info("kept")
+	if !strings.Contains(buf.String(), "msg=kept") {
+		t.Errorf("expected the previous formatter to be kept got %q", buf.String())
+	}
+
+	buf.Reset()
+	h.Formatter = nil
+	l.Info("default")
+	if !strings.HasSuffix(buf.String(), "] default\n") {
+		t.Errorf("expected the default formatter got %q", buf.String())
+	}
+}
+
+func TestHandler_NilInner(t *testing.T) {
+	handlers := []Handler{
+		NewSinkHandler(nil, 10),
+		NewWorkerSinkHandler(nil, 10, 2, false),
+		NewMultiHandler(nil, NewLogRecorder(), nil),
+	}
+
+	for _, h := range handlers {
+		l := NewLogger("nilinner")
+		l.SetHandler(h)
+		l.SetErrorHook(func(err error) { t.Errorf("%T: unexpected error %s", h, err) })
+		h.SetFormatter(&JSONFormatter{})
+		h.SetLevel(DEBUG)
+		l.Info("message")
+		if err := h.Flush(); err != nil {
+			t.Errorf("%T: unexpected flush error %s", h, err)
+		}
+		h.Close()
+	}
+}
+
+func TestMultiHandler_Error(t *testing.T) {
+	r1, r2, r3 := NewLogRecorder(), NewLogRecorder(), NewLogRecorder()
+	r1.Err = errors.New("first")
+	r3.Err = errors.New("third")
+
+	err := NewMultiHandler(r1, r2, r3).Handle(&Record{Format: "record", Level: INFO})
+	if err == nil || err.Error() != "first; third" {
+		t.Errorf("expected the combined errors got %v", err)
+	}
+	if err := NewMultiHandler(r2).Handle(&Record{Format: "record", Level: INFO}); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+}
+
+func TestLogger_SetErrorHook(t *testing.T) {
+	r := NewLogRecorder()
+	r.Err = errors.New("write failed")
+
+	var errs []error
+	l := NewLogger("hook")
+	l.SetHandler(r)
+	l.SetErrorHook(func(err error) { errs = append(errs, err) })
+	l.Info("failing")
+	l.New("child").Error("failing")
+
+	if len(errs) != 2 || errs[0] != r.Err || errs[1] != r.Err {
+		t.Errorf("expected the handler errors to reach the hook got %v", errs)
+	}
+
+	r.Err = nil
+	l.Info("succeeding")
+	if len(errs) != 2 {
+		t.Errorf("hook called without an error")
+	}
+}
+
+// withDefaultLogger runs fn with DefaultLogger replaced by a new logger
+// recording to r.
+func withDefaultLogger(r Handler, fn func()) {
+	defer func(l Logger) { DefaultLogger = l }(DefaultLogger)
+	DefaultLogger = NewLogger("default")
+	SetHandler(r)
+	fn()
+}
+
+func TestSetLevel(t *testing.T) {
+	r := NewLogRecorder()
+	withDefaultLogger(r, func() {
+		SetLevel(ERROR)
+		Info("filtered")
+		Error("kept")
+	})
+
+	recs := r.Records["default"]
+	if len(recs) != 1 || recs[0].Level != ERROR {
+		t.Errorf("expected a single error record got %v", recs)
+	}
+}
+
+func TestSetFormatter(t *testing.T) {
+	r := NewLogRecorder()
+	withDefaultLogger(r, func() {
+		SetFormatter(&JSONFormatter{})
+	})
+
+	if _, ok := r.Formatter.(*JSONFormatter); !ok {
+		t.Errorf("formatter is not set on the handler")
+	}
+}
+
+func TestPackageFunctions(t *testing.T) {
+	r := NewLogRecorder()
+	var line int
+	withDefaultLogger(r, func() {
+		SetLevel(DEBUG)
+		_, _, line, _ = runtime.Caller(0)
+		Critical("critical")
+		Error("error")
+		Warning("warning")
+		Notice("notice")
+		Info("info")
+		Debug("debug")
+		func() {
+			defer func() { recover() }()
+			Panic("panic")
+		}()
+	})
+
+	expected := []level{CRITICAL, ERROR, WARNING, NOTICE, INFO, DEBUG, CRITICAL}
+	offsets := []int{1, 2, 3, 4, 5, 6, 9}
+	recs := r.Records["default"]
+	if len(recs) != len(expected) {
+		t.Fatalf("expected %d records got %d", len(expected), len(recs))
+	}
+	for i, rec := range recs {
+		if rec.Level != expected[i] {
+			t.Errorf("expected level %s got %s", levelNames[expected[i]], levelNames[rec.Level])
+		}
+		if !strings.HasSuffix(rec.Filename, "logger_test.go") || rec.Line != line+offsets[i] {
+			t.Errorf("expected logger_test.go:%d got %s:%d", line+offsets[i], rec.Filename, rec.Line)
+		}
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for l, name := range levelNames {
+		for _, s := range []string{name, strings.ToLower(name), name[:1] + strings.ToLower(name[1:])} {
+			parsed, err := ParseLevel(s)
+			if err != nil {
+				t.Errorf("can not parse %q: %s", s, err)
+			}
+			if parsed != l {
+				t.Errorf("expected %s got %s", l, parsed)
+			}
+		}
+		if l.String() != name {
+			t.Errorf("expected %s got %s", name, l.String())
+		}
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Errorf("expected an error for an unknown level")
+	}
+	if s := level(42).String(); s != "level(42)" {
+		t.Errorf("unexpected name of an unknown level %q", s)
+	}
+}
+
+func TestLevel_JSON(t *testing.T) {
+	type config struct {
+		Level Level `json:"level"`
+	}
+
+	for l, name := range levelNames {
+		b, err := json.Marshal(config{Level: l})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != `{"level":"`+name+`"}` {
+			t.Errorf("unexpected encoding %s", b)
+		}
+
+		var c config
+		if err := json.Unmarshal(b, &c); err != nil {
+			t.Fatal(err)
+		}
+		if c.Level != l {
+			t.Errorf("expected %s got %s", l, c.Level)
+		}
+
+		if err := json.Unmarshal([]byte(fmt.Sprintf(`{"level":%d}`, l)), &c); err != nil || c.Level != l {
+			t.Errorf("can not decode numeric level %d: %v", l, err)
+		}
+	}
+
+	var c config
+	for _, data := range []string{`{"level":"verbose"}`, `{"level":42}`, `{"level":true}`} {
+		if err := json.Unmarshal([]byte(data), &c); err == nil {
+			t.Errorf("expected an error decoding %s", data)
+		}
+	}
+	if _, err := json.Marshal(config{Level: 42}); err == nil {
+		t.Errorf("expected an error encoding an unknown level")
+	}
+}
+
+func TestRegisterLevel(t *testing.T) {
+	const TRACE = DEBUG + 1
+	RegisterLevel(TRACE, "TRACE", BLUE)
+	defer func() {
+		levelsMu.Lock()
+		delete(levelNames, TRACE)
+		delete(levelColors, TRACE)
+		levelsMu.Unlock()
+	}()
+
+	if l, err := ParseLevel("trace"); err != nil || l != TRACE {
+		t.Errorf("can not parse registered level: %v", err)
+	}
+
+	var buf strings.Builder
+	h := NewWriterHandler(&buf)
+	h.Colorize = true
+	h.SetLevel(TRACE)
+
+	l := NewLogger("trace")
+	l.SetHandler(h)
+	l.SetLevel(DEBUG)
+	l.Log(TRACE, "filtered")
+	if buf.Len() != 0 {
+		t.Errorf("trace record is not filtered by a debug logger: %q", buf.String())
+	}
+
+	l.SetLevel(TRACE)
+	l.Log(TRACE, "traced")
+	out := buf.String()
+	if !strings.HasPrefix(out, fmt.Sprintf("\033[%dm", BLUE)) || !strings.Contains(out, " TRACE ") {
+		t.Errorf("expected a blue trace record got %q", out)
+	}
+}
+
+// countingHandler counts the records it handles.
+type countingHandler struct {
+	BaseHandler
+	n int64
+}
+
+func (h *countingHandler) Handle(*Record) error {
+	atomic.AddInt64(&h.n, 1)
+	return nil
+}
+
+func (h *countingHandler) Close() {}
+
+func BenchmarkLogger_Info(b *testing.B) {
+	l := NewLogger("bench")
+	l.SetHandler(&countingHandler{})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("benchmark %d", i)
+	}
+}
+
+func BenchmarkLogger_Disabled(b *testing.B) {
+	l := NewLogger("bench")
+	l.SetLevel(ERROR)
+	l.SetHandler(&countingHandler{})
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Debug("benchmark %d", i)
+	}
+}
+
+func TestLogger_DisabledLevel(t *testing.T) {
+	h := &countingHandler{}
+	l := NewLogger("disabled")
+	l.SetLevel(ERROR)
+	l.SetHandler(h)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		l.Warning("disabled")
+		l.Info("disabled")
+		l.Debug("disabled")
+		l.Log(NOTICE, "disabled")
+	})
+
+	if n := atomic.LoadInt64(&h.n); n != 0 {
+		t.Errorf("expected no handler call got %d", n)
+	}
+	if allocs != 0 {
+		t.Errorf("expected no allocation got %v", allocs)
+	}
+}
+
+func TestLogger_FieldsArgument(t *testing.T) {
+	r := NewLogRecorder()
+	l := NewLogger("fields")
+	l.SetHandler(r)
+
+	l.Info("user %s logged in from %s", "bob", "home", Fields{"ip": "10.0.0.1"})
+	l.Info("no args", Fields{"count": 3})
+	l.Info("map %v", map[string]interface{}{"kept": true})
+
+	ctx := WithFields(gocontext.Background(), Fields{"request": 1, "ip": "ctx"})
+	l.InfoCtx(ctx, "merged", Fields{"ip": "arg"})
+
+	recs := r.Records["fields"]
+	tests := []struct {
+		message string
+		fields  Fields
+	}{
+		{"user bob logged in from home", Fields{"ip": "10.0.0.1"}},
+		{"no args", Fields{"count": 3}},
+		{"map map[kept:true]", nil},
+		{"merged", Fields{"request": 1, "ip": "arg"}},
+	}
+	for i, test := range tests {
+		if msg := recs[i].Message(); msg != test.message {
+			t.Errorf("expected message %q got %q", test.message, msg)
+		}
+		if fmt.Sprint(recs[i].Fields) != fmt.Sprint(test.fields) {
+			t.Errorf("expected fields %v got %v", test.fields, recs[i].Fields)
+		}
+	}
+}
+
+func TestTextFormatters_Fields(t *testing.T) {
+	rec := &Record{
+		Format:   "login %s\n",
+		Args:     []interface{}{"ok"},
+		Level:    INFO,
+		Time:     time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		Filename: "main.go",
+		Line:     1,
+		Fields:   Fields{"user": "bob smith", "attempt": 2},
+	}
+	suffix := `login ok attempt=2 user="bob smith"`
+
+	for _, f := range []Formatter{&TextFormatter{}, &FastFormatter{}, &CustomFormatter{}} {
+		if out := f.Format(rec); !strings.HasSuffix(out, suffix) {
+			t.Errorf("%T: expected suffix %q got %q", f, suffix, out)
+		}
+	}
+
+	rec.Stack = "goroutine 1 [running]:\n"
+	text, fast := (&TextFormatter{}).Format(rec), (&FastFormatter{}).Format(rec)
+	if expected := "2021-03-04 05:06:07 INFO    [main.go:1] " + suffix + "\ngoroutine 1 [running]:"; text != expected || fast != expected {
+		t.Errorf("expected the fields before the stack %q got %q and %q", expected, text, fast)
+	}
+}
+
+func TestTextFormatter_Time(t *testing.T) {
+	tests := []struct {
+		time     time.Time
+		expected string
+	}{
+		{time.Time{}, "0001-01-01 00:00:00 "},
+		{time.Date(2021, 3, 4, 5, 6, 7, 8, time.UTC), "2021-03-04 05:06:07 "},
+		{time.Date(12345, 1, 2, 3, 4, 5, 0, time.UTC), "12345-01-02 03:04:05 "},
+	}
+
+	for _, test := range tests {
+		rec := &Record{Format: "message\n", Level: INFO, Time: test.time}
+		for _, f := range []Formatter{&TextFormatter{}, &FastFormatter{}} {
+			if out := f.Format(rec); !strings.HasPrefix(out, test.expected) {
+				t.Errorf("expected prefix %q got %q", test.expected, out)
+			}
+		}
+	}
+}
+
+func TestTextFormatter_TimePrecision(t *testing.T) {
+	tests := []struct {
+		precision TimePrecision
+		expected  string
+	}{
+		{Seconds, "2021-03-04 05:06:07 "},
+		{Milliseconds, "2021-03-04 05:06:07.012 "},
+		{Microseconds, "2021-03-04 05:06:07.012345 "},
+		{Nanoseconds, "2021-03-04 05:06:07.012345678 "},
+	}
+
+	times := []time.Time{
+		time.Date(2021, 3, 4, 5, 6, 7, 12345678, time.UTC),
+		time.Date(12021, 3, 4, 5, 6, 7, 12345678, time.UTC),
+	}
+	for _, test := range tests {
+		formatters := []Formatter{
+			&TextFormatter{TimePrecision: test.precision},
+			&FastFormatter{TimePrecision: test.precision},
+		}
+		for i, tm := range times {
+			expected := test.expected
+			if i == 1 {
+				expected = "1" + expected
+			}
+			rec := &Record{Format: "message\n", Level: INFO, Time: tm}
+			for _, f := range formatters {
+				if out := f.Format(rec); !strings.HasPrefix(out, expected) {
+					t.Errorf("expected prefix %q got %q", expected, out)
+				}
+			}
+		}
+	}
+}
+
+func TestTextFormatter_TimeLayout(t *testing.T) {
+	tm := time.Date(2021, 3, 4, 5, 6, 7, 123456789, time.FixedZone("CET", 3600))
+	rec := &Record{Format: "message\n", Level: INFO, Time: tm}
+
+	formatters := []Formatter{
+		&TextFormatter{TimeLayout: time.RFC3339Nano, TimePrecision: Milliseconds},
+		&FastFormatter{TimeLayout: time.RFC3339Nano, TimePrecision: Milliseconds},
+	}
+	for _, f := range formatters {
+		out := f.Format(rec)
+		ts := strings.SplitN(out, " ", 2)[0]
+		parsed, err := time.Parse(time.RFC3339Nano, ts)
+		if err != nil {
+			t.Errorf("%T: can not parse %q: %s", f, ts, err)
+			continue
+		}
+		if !parsed.Equal(tm) {
+			t.Errorf("%T: expected %s got %s", f, tm, parsed)
+		}
+	}
+}
+
+func TestTextFormatter_Multiline(t *testing.T) {
+	rec := &Record{
+		Format:   "first\nsecond\n\nfourth\n",
+		Level:    INFO,
+		Time:     time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		Filename: "main.go",
+		Line:     1,
+	}
+	prefix := "2021-03-04 05:06:07 INFO    [main.go:1] "
+
+	tests := []struct {
+		mode     MultilineMode
+		expected string
+	}{
+		{MultilineKeep, prefix + "first\nsecond\n\nfourth"},
+		{MultilineEscape, prefix + `first\nsecond\n\nfourth`},
+		{MultilineIndent, prefix + "first\n\tsecond\n\t\n\tfourth"},
+	}
+	for _, test := range tests {
+		formatters := []Formatter{
+			&TextFormatter{Multiline: test.mode},
+			&FastFormatter{Multiline: test.mode},
+		}
+		for _, f := range formatters {
+			if out := f.Format(rec); out != test.expected {
+				t.Errorf("expected %q got %q", test.expected, out)
+			}
+		}
+	}
+
+	out := (&TextFormatter{Multiline: MultilineEscape}).Format(&Record{Format: "failed\n", Stack: "goroutine 1\n\tmain.go:1\n"})
+	if strings.Count(out, "\n") != 0 || !strings.HasSuffix(out, `failed\ngoroutine 1\n`+"\tmain.go:1") {
+		t.Errorf("expected the stack on the same line got %q", out)
+	}
+}
+
+func TestTextFormatter_TrimPath(t *testing.T) {
+	rec := &Record{
+		Format:   "message",
+		Level:    INFO,
+		Time:     time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		Filename: "/home/build/src/github.com/user/app/internal/db/conn.go",
+		Line:     42,
+	}
+	prefix := "2021-03-04 05:06:07 INFO    "
+
+	tests := []struct {
+		trim     func(string) string
+		expected string
+	}{
+		{nil, "[db/conn.go:42] message"},
+		{filepath.Base, "[conn.go:42] message"},
+		{TrimPathPrefix("/home/build/src/github.com/user/app"), "[internal/db/conn.go:42] message"},
+		{TrimPathPrefix("/home/build/src/github.com/user/app/"), "[internal/db/conn.go:42] message"},
+		{TrimPathPrefix("/home/build/src/github.com/user/ap"), "[db/conn.go:42] message"},
+		{TrimPathPrefix("/elsewhere"), "[db/conn.go:42] message"},
+	}
+	for _, test := range tests {
+		formatters := []Formatter{
+			&TextFormatter{TrimPath: test.trim},
+			&FastFormatter{TrimPath: test.trim},
+		}
+		for _, f := range formatters {
+			if out := f.Format(rec); out != prefix+test.expected {
+				t.Errorf("%T: expected %q got %q", f, prefix+test.expected, out)
+			}
+		}
+	}
+
+	defer func(f func(string) string) { DefaultTrimPath = f }(DefaultTrimPath)
+	DefaultTrimPath = filepath.Base
+	if out := (&TextFormatter{}).Format(rec); out != prefix+"[conn.go:42] message" {
+		t.Errorf("expected the default trimming to be used got %q", out)
+	}
+}
+
+func TestFormatters_MaxMessageLength(t *testing.T) {
+	defer func(n int) { MaxMessageLength = n }(MaxMessageLength)
+	MaxMessageLength = 16
+
+	rec := &Record{
+		Format:     "payload %s\n",
+		Args:       []interface{}{strings.Repeat("x", 10<<20)},
+		LoggerName: "big",
+		Level:      INFO,
+		Time:       time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+	}
+	expected := "payload xxxxxxxx…(truncated 10485752 bytes)"
+
+	formatters := []Formatter{
+		&TextFormatter{},
+		&FastFormatter{},
+		&JSONFormatter{},
+		&LogfmtFormatter{},
+		&CSVFormatter{},
+		&CustomFormatter{},
+		&ECSFormatter{},
+		&GCPFormatter{},
+	}
+	for _, f := range formatters {
+		out := f.Format(rec)
+		if len(out) > 1024 || !strings.Contains(out, expected) {
+			t.Errorf("%T: expected the truncated message %q got %d bytes", f, expected, len(out))
+		}
+	}
+
+	rec.Args = []interface{}{"short"}
+	if out := (&TextFormatter{}).Format(rec); !strings.HasSuffix(out, "] payload short") {
+		t.Errorf("expected short messages to be left untouched got %q", out)
+	}
+}
+
+func TestTruncateMessage(t *testing.T) {
+	defer func(n int) { MaxMessageLength = n }(MaxMessageLength)
+
+	tests := []struct {
+		max      int
+		message  string
+		expected string
+	}{
+		{0, "unlimited", "unlimited"},
+		{5, "exact", "exact"},
+		{5, "exact\n", "exact\n"},
+		{4, "abcdef", "abcd…(truncated 2 bytes)"},
+		{4, "abcdé", "abcd…(truncated 2 bytes)"},
+		{4, "abcé", "abc…(truncated 2 bytes)"},
+		{1, "日本", "…(truncated 6 bytes)"},
+	}
+	for _, test := range tests {
+		MaxMessageLength = test.max
+		if out := truncateMessage(test.message); out != test.expected {
+			t.Errorf("%d %q: expected %q got %q", test.max, test.message, test.expected, out)
+		}
+	}
+}
+
+func TestLogger_Now(t *testing.T) {
+	defer func(f func() time.Time) { now = f }(now)
+	frozen := time.Date(2021, 3, 4, 5, 6, 7, 8, time.UTC)
+	now = func() time.Time { return frozen }
+
+	r := NewLogRecorder()
+	l := NewLogger("clock")
+	l.SetHandler(r)
+	l.Info("first")
+	l.Info("second")
+
+	for _, rec := range r.Records["clock"] {
+		if rec.Time != frozen {
+			t.Errorf("expected time %s got %s", frozen, rec.Time)
+		}
+	}
+}
+
+func TestLogger_SetTimeZone(t *testing.T) {
+	r := NewLogRecorder()
+	l := NewLogger("timezone")
+	l.SetHandler(r)
+
+	l.Info("local")
+	l.SetTimeZone(time.UTC)
+	l.Info("utc")
+	l.New("child").Info("child")
+
+	recs := r.Records["timezone"]
+	if loc := recs[0].Time.Location(); loc != time.Local {
+		t.Errorf("expected local time by default got %s", loc)
+	}
+	for _, rec := range recs[1:] {
+		if loc := rec.Time.Location(); loc != time.UTC {
+			t.Errorf("expected UTC got %s", loc)
+		}
+	}
+}
+
+func TestLogger_SetGoroutineID(t *testing.T) {
+	r := NewLogRecorder()
+	l := NewLogger("goroutine")
+	l.SetHandler(r)
+
+	l.Info("disabled")
+	l.SetGoroutineID(true)
+	for i := 0; i < 2; i++ {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			l.Info("enabled")
+		}()
+		<-done
+	}
+
+	recs := r.Records["goroutine"]
+	if id := recs[0].GoroutineID; id != 0 {
+		t.Errorf("expected no goroutine id by default got %d", id)
+	}
+	if recs[1].GoroutineID == 0 || recs[2].GoroutineID == 0 {
+		t.Fatalf("expected goroutine ids got %d and %d", recs[1].GoroutineID, recs[2].GoroutineID)
+	}
+	if recs[1].GoroutineID == recs[2].GoroutineID {
+		t.Errorf("expected different goroutine ids got %d twice", recs[1].GoroutineID)
+	}
+
+	rec := &Record{Format: "message\n", Level: INFO, GoroutineID: 42}
+	for _, f := range []Formatter{&TextFormatter{}, &FastFormatter{}} {
+		if out := f.Format(rec); !strings.Contains(out, "[goroutine:42]") {
+			t.Errorf("expected goroutine id in %q", out)
+		}
+	}
+	if out := (&JSONFormatter{}).Format(rec); !strings.Contains(out, `"goroutine":42`) {
+		t.Errorf("expected goroutine id in %q", out)
+	}
+}
+
+func logFromNamedFunction(l Logger) {
+	l.Info("named")
+}
+
+func TestLogger_Function(t *testing.T) {
+	r := NewLogRecorder()
+	l := NewLogger("function")
+	l.SetHandler(r)
+
+	logFromNamedFunction(l)
+	FullFunctionNames = true
+	defer func() { FullFunctionNames = false }()
+	logFromNamedFunction(l)
+
+	recs := r.Records["function"]
+	if fn := recs[0].Function; fn != "logger.logFromNamedFunction" {
+		t.Errorf("expected short function name got %q", fn)
+	}
+	if fn := recs[1].Function; fn != pkgPath+".logFromNamedFunction" {
+		t.Errorf("expected full function name got %q", fn)
+	}
+}
+
+func TestShortFuncName(t *testing.T) {
+	tests := map[string]string{
+		"main.main":                                "main.main",
+		"github.com/user/pkg.(*T).Method":          "pkg.(*T).Method",
+		"github.com/user/pkg/v2.Func":              "pkg.Func",
+		"github.com/user/pkg/v2.(*T).Method.func1": "pkg.(*T).Method.func1",
+		"v2.Func":                  "v2.Func",
+		"github.com/user/vet.Func": "vet.Func",
+	}
+	for name, expected := range tests {
+		if got := shortFuncName(name); got != expected {
+			t.Errorf("%s: expected %q got %q", name, expected, got)
+		}
+	}
+}
+
+func logErrorWithStack(l Logger) {
+	l.Error("failed")
+}
+
+func TestLogger_SetStackTrace(t *testing.T) {
+	r := NewLogRecorder()
+	l := NewLogger("stack")
+	l.SetHandler(r)
+
+	logErrorWithStack(l)
+	l.SetStackTrace(true, ERROR)
+	logErrorWithStack(l)
+	l.Warning("below")
+
+	recs := r.Records["stack"]
+	if recs[0].Stack != "" {
+		t.Errorf("expected no stack by default got %q", recs[0].Stack)
+	}
+	if !strings.Contains(recs[1].Stack, "logErrorWithStack") {
+		t.Errorf("expected calling function in stack %q", recs[1].Stack)
+	}
+	if recs[2].Stack != "" {
+		t.Errorf("expected no stack below ERROR got %q", recs[2].Stack)
+	}
+
+	for _, f := range []Formatter{&TextFormatter{}, &FastFormatter{}} {
+		out := f.Format(recs[1])
+		if !strings.HasSuffix(out, "failed\n"+strings.TrimRight(recs[1].Stack, "\n")) {
+			t.Errorf("expected stack after message in %q", out)
+		}
+	}
+}
+
+func TestLogger_Hostname(t *testing.T) {
+	expected, err := os.Hostname()
+	if err != nil {
+		t.Skipf("can not resolve hostname: %s", err)
+	}
+
+	r := NewLogRecorder()
+	l := NewLogger("hostname")
+	l.SetHandler(r)
+	l.Info("host")
+
+	rec := r.Records["hostname"][0]
+	if rec.Hostname == "" || rec.Hostname != expected {
+		t.Errorf("expected hostname %q got %q", expected, rec.Hostname)
+	}
+	if out := (&JSONFormatter{}).Format(rec); !strings.Contains(out, `"host":"`+expected+`"`) {
+		t.Errorf("expected hostname in %q", out)
+	}
+}
+
+func TestLogger_SetLevelConcurrent(t *testing.T) {
+	h := &countingHandler{}
+	l := NewLogger("concurrent")
+	l.SetHandler(h)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					l.Debug("debug")
+					l.Info("info")
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < 1000; i++ {
+		if i%2 == 0 {
+			l.SetLevel(DEBUG)
+		} else {
+			l.SetLevel(ERROR)
+		}
+	}
+	close(stop)
+	wg.Wait()
+
+	l.SetLevel(ERROR)
+	n := atomic.LoadInt64(&h.n)
+	l.Info("filtered")
+	if atomic.LoadInt64(&h.n) != n {
+		t.Errorf("record below the level was handled")
+	}
+}
+
+func TestLogger_IsEnabled(t *testing.T) {
+	l := NewLogger("enabled")
+	l.SetLevel(WARNING)
+
+	for _, lv := range []level{CRITICAL, ERROR, WARNING} {
+		if !l.IsEnabled(lv) {
+			t.Errorf("expected %s to be enabled at WARNING", lv)
+		}
+	}
+	for _, lv := range []level{NOTICE, INFO, DEBUG} {
+		if l.IsEnabled(lv) {
+			t.Errorf("expected %s to be disabled at WARNING", lv)
+		}
+	}
+
+	l.SetLevel(DEBUG)
+	if !l.IsEnabled(DEBUG) || !l.New("child").IsEnabled(DEBUG) {
+		t.Errorf("expected DEBUG to be enabled after SetLevel")
+	}
+}
+
+func TestLogger_LazyArgs(t *testing.T) {
+	r := NewLogRecorder()
+	l := NewLogger("lazy")
+	l.SetHandler(r)
+
+	calls := 0
+	expensive := func() interface{} {
+		calls++
+		return "computed"
+	}
+
+	l.Debug("suppressed %v", expensive)
+	if calls != 0 {
+		t.Errorf("expected no call for a suppressed level got %d", calls)
+	}
+
+	args := []interface{}{1, expensive}
+	l.Info("value %d %v", args...)
+	if calls != 1 {
+		t.Errorf("expected one call got %d", calls)
+	}
+	if msg := r.Records["lazy"][0].Message(); msg != "value 1 computed" {
+		t.Errorf("unexpected message %q", msg)
+	}
+	if _, ok := args[1].(func() interface{}); !ok {
+		t.Errorf("arguments of the caller are modified")
+	}
+}
+
+// panickingHandler panics handling records with the message "panic".
+type panickingHandler struct {
+	*LogRecorder
+}
+
+func (h panickingHandler) Handle(rec *Record) error {
+	if rec.Message() == "panic" {
+		panic("handler failure")
+	}
+	return h.LogRecorder.Handle(rec)
+}
+
+func TestLogger_HandlerPanic(t *testing.T) {
+	h := panickingHandler{NewLogRecorder()}
+
+	var errs []error
+	l := NewLogger("panic")
+	l.SetHandler(NewMultiHandler(h))
+	l.SetErrorHook(func(err error) { errs = append(errs, err) })
+	l.Info("panic")
+	l.Info("after")
+
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "handler failure") {
+		t.Errorf("expected the panic to reach the hook got %v", errs)
+	}
+	if recs := h.Records["panic"]; len(recs) != 1 || recs[0].Message() != "after" {
+		t.Errorf("expected logging to continue after the panic")
+	}
+}
@@ -2,7 +2,9 @@
 
 package logger
 
+import "os"
+
 func init() {
-	stdoutHandler.Colorize = true
-	stderrHandler.Colorize = true
+	StdoutHandler.Colorize = colorEnabled(os.Stdout)
+	StderrHandler.Colorize = colorEnabled(os.Stderr)
 }
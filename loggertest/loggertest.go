@@ -0,0 +1,140 @@
+// Package loggertest provides a handler recording log records for tests.
+package loggertest
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/ducksoso/logger/v2"
+)
+
+// Recorder is a logger.Handler keeping a copy of every record it handles,
+// for assertions in tests. It is safe for concurrent use.
+type Recorder struct {
+	mu        sync.Mutex
+	level     logger.Level
+	formatter logger.Formatter
+	records   []*logger.Record
+	flushed   int
+	closed    bool
+}
+
+var _ logger.Handler = (*Recorder)(nil)
+
+// NewRecorder creates a new recorder recording the records of all levels.
+func NewRecorder() *Recorder {
+	return &Recorder{level: logger.DEBUG}
+}
+
+// SetLevel sets the least severe level of the recorded records.
+func (r *Recorder) SetLevel(l logger.Level) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.level = l
+}
+
+// SetFormatter sets the formatter used by Contains.
+func (r *Recorder) SetFormatter(f logger.Formatter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.formatter = f
+}
+
+// Handle records a copy of rec.
+func (r *Recorder) Handle(rec *logger.Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rec.Level > r.level {
+		return nil
+	}
+	c := *rec
+	r.records = append(r.records, &c)
+	return nil
+}
+
+// Flush counts the flushes reported by Flushed.
+func (r *Recorder) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.flushed++
+	return nil
+}
+
+// Close marks the recorder closed, records are still recorded.
+func (r *Recorder) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+}
+
+// All returns the recorded records, oldest first.
+func (r *Recorder) All() []*logger.Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*logger.Record(nil), r.records...)
+}
+
+// Records returns the recorded records of the given level, oldest first.
+func (r *Recorder) Records(l logger.Level) []*logger.Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var recs []*logger.Record
+	for _, rec := range r.records {
+		if rec.Level == l {
+			recs = append(recs, rec)
+		}
+	}
+	return recs
+}
+
+// LastRecord returns the most recent record, nil if there is none.
+func (r *Recorder) LastRecord() *logger.Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.records) == 0 {
+		return nil
+	}
+	return r.records[len(r.records)-1]
+}
+
+// Contains reports whether a recorded message contains substring. Messages
+// are formatted with the formatter of the recorder if it is set.
+func (r *Recorder) Contains(substring string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rec := range r.records {
+		msg := rec.Message()
+		if r.formatter != nil {
+			msg = r.formatter.Format(rec)
+		}
+		if strings.Contains(msg, substring) {
+			return true
+		}
+	}
+	return false
+}
+
+// Flushed returns the number of times the recorder was flushed.
+func (r *Recorder) Flushed() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.flushed
+}
+
+// Closed reports whether the recorder was closed.
+func (r *Recorder) Closed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closed
+}
+
+// Reset discards the recorded records.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records = nil
+}
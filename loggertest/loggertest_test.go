@@ -0,0 +1,86 @@
+package loggertest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/ducksoso/logger/v2"
+)
+
+func TestRecorder(t *testing.T) {
+	r := NewRecorder()
+	if r.LastRecord() != nil {
+		t.Errorf("expected no record")
+	}
+
+	l := logger.NewLogger("test")
+	l.SetLevel(logger.DEBUG)
+	l.SetHandler(r)
+	l.Info("first %d", 1)
+	l.Error("failed: %s", "timeout")
+	l.Info("second %d", 2)
+
+	if rec := r.LastRecord(); rec == nil || rec.Message() != "second 2" {
+		t.Errorf("unexpected last record %v", rec)
+	}
+	if recs := r.Records(logger.INFO); len(recs) != 2 || recs[0].Message() != "first 1" {
+		t.Errorf("unexpected INFO records %v", recs)
+	}
+	if recs := r.Records(logger.ERROR); len(recs) != 1 || recs[0].LoggerName != "test" {
+		t.Errorf("unexpected ERROR records %v", recs)
+	}
+	if recs := r.Records(logger.DEBUG); len(recs) != 0 {
+		t.Errorf("unexpected DEBUG records %v", recs)
+	}
+	if !r.Contains("timeout") || r.Contains("missing") {
+		t.Errorf("unexpected Contains result")
+	}
+	if n := len(r.All()); n != 3 {
+		t.Errorf("expected 3 records got %d", n)
+	}
+
+	r.SetFormatter(&logger.JSONFormatter{})
+	if !r.Contains(`"level":"ERROR"`) {
+		t.Errorf("expected Contains to match the formatted record")
+	}
+
+	r.SetLevel(logger.WARNING)
+	l.Info("filtered")
+	if r.Contains("filtered") {
+		t.Errorf("record below the level is recorded")
+	}
+
+	r.Flush()
+	r.Close()
+	if r.Flushed() != 1 || !r.Closed() {
+		t.Errorf("expected the recorder to be flushed and closed")
+	}
+
+	r.Reset()
+	if r.LastRecord() != nil {
+		t.Errorf("expected no record after Reset")
+	}
+}
+
+func TestRecorder_Concurrent(t *testing.T) {
+	r := NewRecorder()
+	l := logger.NewLogger("concurrent")
+	l.SetHandler(r)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				l.Info("record %d", j)
+				r.LastRecord()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := len(r.Records(logger.INFO)); n != 800 {
+		t.Errorf("expected 800 records got %d", n)
+	}
+}
@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// LokiHandler pushes the logger output in batches to the push API of Grafana
+// Loki, e.g. "http://localhost:3100/loki/api/v1/push".
+//
+// Records are grouped in streams labeled with their logger name and level.
+// The default formatter is LogfmtFormatter. Batching, retries and gzip
+// compression work as for HTTPHandler.
+type LokiHandler struct {
+	*HTTPHandler
+}
+
+// lokiPush is the body of a Loki push request.
+type lokiPush struct {
+	Streams []*lokiStream `json:"streams"`
+}
+
+// lokiStream holds the lines of a stream as [timestamp, line] pairs, with
+// the timestamp in nanoseconds.
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
+
+// NewLokiHandler creates a new Loki handler pushing batches of up to
+// batchSize records to url at least every flushInterval.
+func NewLokiHandler(url string, batchSize int, flushInterval time.Duration) *LokiHandler {
+	h := newHTTPHandler(url, batchSize, flushInterval, "application/json", encodeLokiPush)
+	h.Formatter = &LogfmtFormatter{}
+	return &LokiHandler{HTTPHandler: h}
+}
+
+// encodeLokiPush encodes the batch as a Loki push request with a stream per
+// logger and level, in the order of their first record.
+func encodeLokiPush(batch []batchEntry) ([]byte, error) {
+	var push lokiPush
+	streams := make(map[[2]string]*lokiStream)
+	for _, e := range batch {
+		key := [2]string{e.rec.LoggerName, e.rec.Level.String()}
+		s, ok := streams[key]
+		if !ok {
+			s = &lokiStream{Stream: map[string]string{"logger": key[0], "level": key[1]}}
+			streams[key] = s
+			push.Streams = append(push.Streams, s)
+		}
+		s.Values = append(s.Values, [2]string{strconv.FormatInt(e.rec.Time.UnixNano(), 10), e.message})
+	}
+	return json.Marshal(push)
+}
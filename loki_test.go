@@ -0,0 +1,97 @@
+package logger
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLokiHandler(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		pushes []lokiPush
+	)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body = zr
+		}
+
+		var push lokiPush
+		if err := json.NewDecoder(body).Decode(&push); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		pushes = append(pushes, push)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer s.Close()
+
+	h := NewLokiHandler(s.URL, 4, time.Hour)
+	h.Gzip = true
+	h.SetFormatter(&messageFormatter{})
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	records := []struct {
+		name  string
+		level level
+	}{
+		{"api", INFO}, {"db", ERROR}, {"api", INFO}, {"api", WARNING},
+		{"db", ERROR},
+	}
+	for i, r := range records {
+		h.Handle(&Record{Format: "record " + strconv.Itoa(i), LoggerName: r.name, Level: r.level, Time: now.Add(time.Duration(i))})
+	}
+	h.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(pushes) != 2 {
+		t.Fatalf("expected 2 pushes got %d", len(pushes))
+	}
+
+	streams := pushes[0].Streams
+	if len(streams) != 3 {
+		t.Fatalf("expected 3 streams in the first push got %d", len(streams))
+	}
+	expected := []struct {
+		logger, level string
+		values        [][2]string
+	}{
+		{"api", "INFO", [][2]string{{strconv.FormatInt(now.UnixNano(), 10), "record 0"}, {strconv.FormatInt(now.UnixNano()+2, 10), "record 2"}}},
+		{"db", "ERROR", [][2]string{{strconv.FormatInt(now.UnixNano()+1, 10), "record 1"}}},
+		{"api", "WARNING", [][2]string{{strconv.FormatInt(now.UnixNano()+3, 10), "record 3"}}},
+	}
+	for i, e := range expected {
+		s := streams[i]
+		if s.Stream["logger"] != e.logger || s.Stream["level"] != e.level {
+			t.Errorf("unexpected labels %v", s.Stream)
+		}
+		if len(s.Values) != len(e.values) {
+			t.Errorf("expected values %v got %v", e.values, s.Values)
+			continue
+		}
+		for j := range e.values {
+			if s.Values[j] != e.values[j] {
+				t.Errorf("expected value %v got %v", e.values[j], s.Values[j])
+			}
+		}
+	}
+
+	if s := pushes[1].Streams; len(s) != 1 || s[0].Stream["logger"] != "db" || len(s[0].Values) != 1 {
+		t.Errorf("unexpected second push %v", pushes[1])
+	}
+}
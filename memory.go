@@ -0,0 +1,60 @@
+package logger
+
+import "sync"
+
+// MemoryHandler is a handler implementation retaining the last records in a
+// ring buffer, e.g. to dump the recent context from a panic handler.
+type MemoryHandler struct {
+	*BaseHandler
+	mu      sync.Mutex
+	records []Record
+	next    int  // next is the index of the next record to overwrite
+	full    bool // full reports whether the buffer wrapped around
+}
+
+// NewMemoryHandler creates a new memory handler retaining the last size records.
+func NewMemoryHandler(size int) *MemoryHandler {
+	return &MemoryHandler{
+		BaseHandler: NewBaseHandler(),
+		records:     make([]Record, size),
+	}
+}
+
+// Handle stores a copy of the record, replacing the oldest one if the buffer is full.
+func (h *MemoryHandler) Handle(rec *Record) error {
+	if h.Level < rec.Level || len(h.records) == 0 {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.records[h.next] = *rec
+	h.next++
+	if h.next == len(h.records) {
+		h.next = 0
+		h.full = true
+	}
+	return nil
+}
+
+// Snapshot returns copies of the retained records, oldest first.
+func (h *MemoryHandler) Snapshot() []*Record {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var recs []Record
+	if h.full {
+		recs = append(recs, h.records[h.next:]...)
+	}
+	recs = append(recs, h.records[:h.next]...)
+
+	snapshot := make([]*Record, len(recs))
+	for i := range recs {
+		snapshot[i] = &recs[i]
+	}
+	return snapshot
+}
+
+// Close does nothing, the records stay available.
+func (h *MemoryHandler) Close() {}
@@ -0,0 +1,37 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMemoryHandler_Snapshot(t *testing.T) {
+	h := NewMemoryHandler(3)
+
+	l := NewLogger("memory")
+	l.SetHandler(h)
+	l.Info("record 0")
+	if recs := h.Snapshot(); len(recs) != 1 || recs[0].Message() != "record 0" {
+		t.Fatalf("unexpected snapshot %v", recs)
+	}
+
+	for i := 1; i < 5; i++ {
+		l.Info("record %d", i)
+	}
+	l.Debug("filtered")
+
+	recs := h.Snapshot()
+	if len(recs) != 3 {
+		t.Fatalf("expected 3 records got %d", len(recs))
+	}
+	for i, rec := range recs {
+		if msg := rec.Message(); msg != fmt.Sprintf("record %d", i+2) {
+			t.Errorf("expected record %d got %q", i+2, msg)
+		}
+	}
+
+	recs[0].Format = "modified"
+	if msg := h.Snapshot()[0].Message(); msg != "record 2" {
+		t.Errorf("snapshot shares records with the handler: %q", msg)
+	}
+}
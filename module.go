@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// levelEnv is the environment variable setting the default level. The levels
+// of modules are set by variables suffixed with an underscore and the module
+// name, e.g. LOG_LEVEL_db=DEBUG.
+const levelEnv = "LOG_LEVEL"
+
+var (
+	modulesMu    sync.RWMutex
+	moduleLevels = map[string]level{}
+)
+
+// SetModuleLevel sets the level of the loggers created with NewLogger for
+// the module name and its submodules. Modules are dot separated, a logger
+// named "db.pool" takes the level of "db.pool" if set, else that of "db".
+// Loggers of modules without a level use DefaultLevel.
+func SetModuleLevel(name string, l Level) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+	moduleLevels[name] = l
+}
+
+// moduleLevel returns the level of the most specific module of name.
+func moduleLevel(name string) (level, bool) {
+	modulesMu.RLock()
+	defer modulesMu.RUnlock()
+
+	for {
+		if l, ok := moduleLevels[name]; ok {
+			return l, true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return 0, false
+		}
+		name = name[:i]
+	}
+}
+
+// loadEnvLevels registers the module levels set in the environment and
+// returns the default level, INFO if it is not set or invalid.
+func loadEnvLevels() level {
+	def := INFO
+	for _, kv := range os.Environ() {
+		i := strings.IndexByte(kv, '=')
+		if i < 0 || !strings.HasPrefix(kv[:i], levelEnv) {
+			continue
+		}
+		key, value := kv[:i], kv[i+1:]
+
+		l, err := ParseLevel(value)
+		if err != nil {
+			continue
+		}
+		switch {
+		case key == levelEnv:
+			def = l
+		case strings.HasPrefix(key, levelEnv+"_") && len(key) > len(levelEnv)+1:
+			SetModuleLevel(key[len(levelEnv)+1:], l)
+		}
+	}
+	return def
+}
@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"os"
+	"testing"
+)
+
+// withModuleLevels runs fn with an empty module level registry.
+func withModuleLevels(fn func()) {
+	modulesMu.Lock()
+	saved := moduleLevels
+	moduleLevels = map[string]level{}
+	modulesMu.Unlock()
+
+	defer func() {
+		modulesMu.Lock()
+		moduleLevels = saved
+		modulesMu.Unlock()
+	}()
+	fn()
+}
+
+func TestSetModuleLevel(t *testing.T) {
+	withModuleLevels(func() {
+		SetModuleLevel("db", DEBUG)
+		SetModuleLevel("db.pool", ERROR)
+
+		tests := []struct {
+			name     string
+			expected level
+		}{
+			{"db", DEBUG},
+			{"db.conn", DEBUG},
+			{"db.conn.tls", DEBUG},
+			{"db.pool", ERROR},
+			{"db.pool.idle", ERROR},
+			{"dbx", DefaultLevel},
+			{"http", DefaultLevel},
+		}
+		for _, test := range tests {
+			if lv := NewLogger(test.name).(*logger).currentLevel(); lv != test.expected {
+				t.Errorf("expected %s for %q got %s", test.expected, test.name, lv)
+			}
+		}
+	})
+}
+
+// setenv sets the environment variable key until the returned function is called.
+func setenv(key, value string) func() {
+	old, ok := os.LookupEnv(key)
+	os.Setenv(key, value)
+	return func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func TestLoadEnvLevels(t *testing.T) {
+	defer func(l level) { DefaultLevel = l }(DefaultLevel)
+
+	tests := []struct {
+		env      map[string]string
+		name     string
+		expected level
+	}{
+		{map[string]string{}, "app", INFO},
+		{map[string]string{"LOG_LEVEL": "debug"}, "app", DEBUG},
+		{map[string]string{"LOG_LEVEL": "verbose"}, "app", INFO},
+		{map[string]string{"LOG_LEVEL": "error", "LOG_LEVEL_db": "DEBUG"}, "db.pool", DEBUG},
+		{map[string]string{"LOG_LEVEL": "error", "LOG_LEVEL_db": "DEBUG"}, "app", ERROR},
+	}
+	for _, test := range tests {
+		withModuleLevels(func() {
+			var restore []func()
+			for _, key := range []string{"LOG_LEVEL", "LOG_LEVEL_db"} {
+				restore = append(restore, setenv(key, test.env[key]))
+				if _, ok := test.env[key]; !ok {
+					os.Unsetenv(key)
+				}
+			}
+			defer func() {
+				for _, fn := range restore {
+					fn()
+				}
+			}()
+
+			DefaultLevel = loadEnvLevels()
+			if lv := NewLogger(test.name).(*logger).currentLevel(); lv != test.expected {
+				t.Errorf("expected %s for %q with %v got %s", test.expected, test.name, test.env, lv)
+			}
+		})
+	}
+}
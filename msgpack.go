@@ -0,0 +1,302 @@
+package logger
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// MsgPackFormatter formats records as MessagePack maps, a compact binary
+// alternative to JSONFormatter for shipping records over constrained links.
+// The map holds the keys of JSONFormatter, including the structured fields
+// of the record under "fields", plus "process". The time is encoded as a
+// MessagePack timestamp.
+//
+// Handlers writing lines append a newline byte to each record, which readers
+// of such output must skip between records.
+type MsgPackFormatter struct {
+	// DisableCaller omits the file, line and function of the log call from
+	// the output.
+	DisableCaller bool
+}
+
+// msgpackPair is a key and value of a map encoded by MsgPackFormatter.
+type msgpackPair struct {
+	key   string
+	value interface{}
+}
+
+func (f *MsgPackFormatter) Format(rec *Record) string {
+	pairs := make([]msgpackPair, 0, 16)
+	add := func(key string, value interface{}) {
+		pairs = append(pairs, msgpackPair{key, value})
+	}
+
+	add("logger", rec.LoggerName)
+	add("message", truncateMessage(rec.Message()))
+	if !f.DisableCaller && rec.Filename != "" {
+		add("file", rec.Filename)
+		add("line", rec.Line)
+		add("function", rec.Function)
+	}
+	if rec.Hostname != "" {
+		add("host", rec.Hostname)
+	}
+	add("pid", rec.ProcessID)
+	if rec.ProcessName != "" {
+		add("process", rec.ProcessName)
+	}
+	if rec.GoroutineID != 0 {
+		add("goroutine", rec.GoroutineID)
+	}
+	if rec.Stack != "" {
+		add("stack", rec.Stack)
+	}
+	if err := recordError(rec); err != nil {
+		add("error", err.Error())
+		if chain := errorChain(err); len(chain) > 0 {
+			chained := make([]interface{}, len(chain))
+			for i, cause := range chain {
+				chained[i] = cause
+			}
+			add("error_chain", chained)
+		}
+	}
+	if len(rec.Fields) > 0 {
+		add("fields", map[string]interface{}(rec.Fields))
+	}
+
+	b := make([]byte, 0, 256)
+	b = appendMsgpackMapHeader(b, len(pairs)+2)
+	b = appendMsgpackString(b, "time")
+	b = appendMsgpackTimestamp(b, rec.Time)
+	for _, p := range pairs {
+		b = appendMsgpackString(b, p.key)
+		b = appendMsgpack(b, p.value)
+	}
+	// The level comes last: its name never ends with a newline, so handlers
+	// trimming the trailing newline of messages leave the encoding intact.
+	b = appendMsgpackString(b, "level")
+	b = appendMsgpackString(b, rec.Level.String())
+	return string(b)
+}
+
+// appendMsgpack appends the MessagePack encoding of v to b. Values of types
+// without a MessagePack counterpart are encoded as strings in the manner of
+// fmt.Sprint, and times as the standard timestamp extension.
+func appendMsgpack(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0)
+	case bool:
+		if v {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case int:
+		return appendMsgpackInt(b, int64(v))
+	case int8:
+		return appendMsgpackInt(b, int64(v))
+	case int16:
+		return appendMsgpackInt(b, int64(v))
+	case int32:
+		return appendMsgpackInt(b, int64(v))
+	case int64:
+		return appendMsgpackInt(b, v)
+	case uint:
+		return appendMsgpackUint(b, uint64(v))
+	case uint8:
+		return appendMsgpackUint(b, uint64(v))
+	case uint16:
+		return appendMsgpackUint(b, uint64(v))
+	case uint32:
+		return appendMsgpackUint(b, uint64(v))
+	case uint64:
+		return appendMsgpackUint(b, v)
+	case float32:
+		b = append(b, 0xca)
+		return appendUint32(b, math.Float32bits(v))
+	case float64:
+		b = append(b, 0xcb)
+		return appendUint64(b, math.Float64bits(v))
+	case string:
+		return appendMsgpackString(b, v)
+	case []byte:
+		return appendMsgpackBinary(b, v)
+	case time.Time:
+		return appendMsgpackTimestamp(b, v)
+	case []interface{}:
+		b = appendMsgpackArrayHeader(b, len(v))
+		for _, e := range v {
+			b = appendMsgpack(b, e)
+		}
+		return b
+	case Fields:
+		return appendMsgpackMap(b, v)
+	case map[string]interface{}:
+		return appendMsgpackMap(b, v)
+	case error:
+		return appendMsgpackString(b, v.Error())
+	default:
+		return appendMsgpackString(b, fmt.Sprint(v))
+	}
+}
+
+// appendMsgpackInt appends n in its most compact encoding.
+func appendMsgpackInt(b []byte, n int64) []byte {
+	switch {
+	case n >= 0:
+		return appendMsgpackUint(b, uint64(n))
+	case n >= -32:
+		return append(b, byte(n))
+	case n >= math.MinInt8:
+		return append(b, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		b = append(b, 0xd1)
+		return appendUint16(b, uint16(n))
+	case n >= math.MinInt32:
+		b = append(b, 0xd2)
+		return appendUint32(b, uint32(n))
+	default:
+		b = append(b, 0xd3)
+		return appendUint64(b, uint64(n))
+	}
+}
+
+// appendMsgpackUint appends n in its most compact encoding.
+func appendMsgpackUint(b []byte, n uint64) []byte {
+	switch {
+	case n <= 0x7f:
+		return append(b, byte(n))
+	case n <= math.MaxUint8:
+		return append(b, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		b = append(b, 0xcd)
+		return appendUint16(b, uint16(n))
+	case n <= math.MaxUint32:
+		b = append(b, 0xce)
+		return appendUint32(b, uint32(n))
+	default:
+		b = append(b, 0xcf)
+		return appendUint64(b, n)
+	}
+}
+
+// appendMsgpackString appends s as a str.
+func appendMsgpackString(b []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n <= 31:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = append(b, 0xda)
+		b = appendUint16(b, uint16(n))
+	default:
+		b = append(b, 0xdb)
+		b = appendUint32(b, uint32(n))
+	}
+	return append(b, s...)
+}
+
+// appendMsgpackBinary appends p as a bin.
+func appendMsgpackBinary(b []byte, p []byte) []byte {
+	n := len(p)
+	switch {
+	case n <= math.MaxUint8:
+		b = append(b, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		b = append(b, 0xc5)
+		b = appendUint16(b, uint16(n))
+	default:
+		b = append(b, 0xc6)
+		b = appendUint32(b, uint32(n))
+	}
+	return append(b, p...)
+}
+
+// appendMsgpackArrayHeader appends the header of an array of n elements.
+func appendMsgpackArrayHeader(b []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(b, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		b = append(b, 0xdc)
+		return appendUint16(b, uint16(n))
+	default:
+		b = append(b, 0xdd)
+		return appendUint32(b, uint32(n))
+	}
+}
+
+// appendMsgpackMapHeader appends the header of a map of n pairs.
+func appendMsgpackMapHeader(b []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(b, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		b = append(b, 0xde)
+		return appendUint16(b, uint16(n))
+	default:
+		b = append(b, 0xdf)
+		return appendUint32(b, uint32(n))
+	}
+}
+
+// appendMsgpackMap appends m with its keys sorted.
+func appendMsgpackMap(b []byte, m map[string]interface{}) []byte {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b = appendMsgpackMapHeader(b, len(m))
+	for _, k := range keys {
+		b = appendMsgpackString(b, k)
+		b = appendMsgpack(b, m[k])
+	}
+	return b
+}
+
+// appendMsgpackTimestamp appends t as the standard timestamp extension, in
+// its 64-bit form when it fits and in its 96-bit form otherwise.
+func appendMsgpackTimestamp(b []byte, t time.Time) []byte {
+	sec, nsec := t.Unix(), uint64(t.Nanosecond())
+	if sec>>34 == 0 {
+		b = append(b, 0xd7, 0xff)
+		return appendUint64(b, nsec<<34|uint64(sec))
+	}
+	b = append(b, 0xc7, 12, 0xff)
+	b = appendUint32(b, uint32(nsec))
+	return appendUint64(b, uint64(sec))
+}
+
+// appendMsgpackEventTime appends t as the EventTime extension of the
+// Fluentd Forward protocol, which only readers of that protocol decode.
+func appendMsgpackEventTime(b []byte, t time.Time) []byte {
+	b = append(b, 0xd7, 0x00)
+	b = appendUint32(b, uint32(t.Unix()))
+	return appendUint32(b, uint32(t.Nanosecond()))
+}
+
+func appendUint16(b []byte, n uint16) []byte {
+	var buf [2]byte
+	binary.BigEndian.PutUint16(buf[:], n)
+	return append(b, buf[:]...)
+}
+
+func appendUint32(b []byte, n uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], n)
+	return append(b, buf[:]...)
+}
+
+func appendUint64(b []byte, n uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], n)
+	return append(b, buf[:]...)
+}
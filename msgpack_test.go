@@ -0,0 +1,297 @@
+package logger
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// decodeMsgpack decodes the first MessagePack value of b and returns it with
+// the rest of b. Integers are decoded as int64 or uint64, maps as
+// map[string]interface{} and EventTime and timestamp extensions as time.Time.
+func decodeMsgpack(b []byte) (interface{}, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, errors.New("unexpected end of input")
+	}
+	c, b := b[0], b[1:]
+
+	switch {
+	case c <= 0x7f:
+		return uint64(c), b, nil
+	case c >= 0xe0:
+		return int64(int8(c)), b, nil
+	case c&0xe0 == 0xa0:
+		return decodeMsgpackString(b, int(c&0x1f))
+	case c&0xf0 == 0x90:
+		return decodeMsgpackArray(b, int(c&0x0f))
+	case c&0xf0 == 0x80:
+		return decodeMsgpackMap(b, int(c&0x0f))
+	}
+
+	sizes := map[byte]int{
+		0xcc: 1, 0xcd: 2, 0xce: 4, 0xcf: 8,
+		0xd0: 1, 0xd1: 2, 0xd2: 4, 0xd3: 8,
+		0xca: 4, 0xcb: 8, 0xd9: 1, 0xda: 2, 0xdb: 4,
+		0xc4: 1, 0xc5: 2, 0xc6: 4, 0xdc: 2, 0xdd: 4,
+		0xde: 2, 0xdf: 4, 0xd7: 9, 0xc7: 2,
+	}
+	size := sizes[c]
+	if len(b) < size {
+		return nil, nil, errors.New("unexpected end of input")
+	}
+	var n uint64
+	for _, x := range b[:size] {
+		n = n<<8 | uint64(x)
+	}
+	p, b := b[:size], b[size:]
+
+	switch c {
+	case 0xc0:
+		return nil, b, nil
+	case 0xc2:
+		return false, b, nil
+	case 0xc3:
+		return true, b, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return n, b, nil
+	case 0xd0:
+		return int64(int8(n)), b, nil
+	case 0xd1:
+		return int64(int16(n)), b, nil
+	case 0xd2:
+		return int64(int32(n)), b, nil
+	case 0xd3:
+		return int64(n), b, nil
+	case 0xca:
+		return math.Float32frombits(uint32(n)), b, nil
+	case 0xcb:
+		return math.Float64frombits(n), b, nil
+	case 0xd9, 0xda, 0xdb:
+		return decodeMsgpackString(b, int(n))
+	case 0xc4, 0xc5, 0xc6:
+		if len(b) < int(n) {
+			return nil, nil, errors.New("unexpected end of input")
+		}
+		return b[:n], b[n:], nil
+	case 0xdc, 0xdd:
+		return decodeMsgpackArray(b, int(n))
+	case 0xde, 0xdf:
+		return decodeMsgpackMap(b, int(n))
+	case 0xd7:
+		switch p[0] {
+		case 0x00:
+			sec := binary.BigEndian.Uint32(p[1:5])
+			nsec := binary.BigEndian.Uint32(p[5:9])
+			return time.Unix(int64(sec), int64(nsec)), b, nil
+		case 0xff:
+			n := binary.BigEndian.Uint64(p[1:9])
+			return time.Unix(int64(n&(1<<34-1)), int64(n>>34)), b, nil
+		}
+		return nil, nil, errors.New("unexpected extension type")
+	case 0xc7:
+		if p[0] != 12 || p[1] != 0xff || len(b) < 12 {
+			return nil, nil, errors.New("unexpected extension")
+		}
+		nsec := binary.BigEndian.Uint32(b[:4])
+		sec := binary.BigEndian.Uint64(b[4:12])
+		return time.Unix(int64(sec), int64(nsec)), b[12:], nil
+	}
+	return nil, nil, errors.New("unexpected type")
+}
+
+func decodeMsgpackString(b []byte, n int) (interface{}, []byte, error) {
+	if len(b) < n {
+		return nil, nil, errors.New("unexpected end of input")
+	}
+	return string(b[:n]), b[n:], nil
+}
+
+func decodeMsgpackArray(b []byte, n int) (interface{}, []byte, error) {
+	a := make([]interface{}, n)
+	for i := range a {
+		var err error
+		if a[i], b, err = decodeMsgpack(b); err != nil {
+			return nil, nil, err
+		}
+	}
+	return a, b, nil
+}
+
+func decodeMsgpackMap(b []byte, n int) (interface{}, []byte, error) {
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, rest, err := decodeMsgpack(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, nil, errors.New("unexpected map key")
+		}
+		if m[key], b, err = decodeMsgpack(rest); err != nil {
+			return nil, nil, err
+		}
+	}
+	return m, b, nil
+}
+
+func TestAppendMsgpack(t *testing.T) {
+	long := string(make([]byte, 300))
+	tm := time.Unix(1500000000, 123456789)
+
+	tests := []struct {
+		value    interface{}
+		expected interface{}
+	}{
+		{nil, nil},
+		{true, true},
+		{false, false},
+		{0, uint64(0)},
+		{200, uint64(200)},
+		{70000, uint64(70000)},
+		{uint64(math.MaxUint64), uint64(math.MaxUint64)},
+		{-1, int64(-1)},
+		{-100, int64(-100)},
+		{-40000, int64(-40000)},
+		{int64(math.MinInt64), int64(math.MinInt64)},
+		{float32(1.5), float32(1.5)},
+		{2.25, 2.25},
+		{"short", "short"},
+		{long, long},
+		{[]byte{1, 2}, []byte{1, 2}},
+		{tm, tm},
+		{[]interface{}{1, "a"}, []interface{}{uint64(1), "a"}},
+		{Fields{"k": "v"}, map[string]interface{}{"k": "v"}},
+		{errors.New("failed"), "failed"},
+		{time.Second, "1s"},
+	}
+
+	for _, test := range tests {
+		v, rest, err := decodeMsgpack(appendMsgpack(nil, test.value))
+		if err != nil {
+			t.Errorf("%v: %s", test.value, err)
+			continue
+		}
+		if len(rest) != 0 {
+			t.Errorf("%v: %d trailing bytes", test.value, len(rest))
+		}
+		if tv, ok := v.(time.Time); ok && tv.Equal(tm) {
+			continue
+		}
+		if !reflect.DeepEqual(v, test.expected) {
+			t.Errorf("expected %#v got %#v", test.expected, v)
+		}
+	}
+}
+
+func TestMsgPackFormatter_Format(t *testing.T) {
+	tm := time.Date(2021, 3, 4, 5, 6, 7, 890, time.UTC)
+	rec := &Record{
+		Format:      "request %s failed: %s\n",
+		Args:        []interface{}{"/index", fmt.Errorf("timeout: %w", errors.New("dial"))},
+		LoggerName:  "web",
+		Level:       ERROR,
+		Time:        tm,
+		Filename:    "/src/app/main.go",
+		Line:        12,
+		Function:    "main.serve",
+		ProcessID:   1234,
+		ProcessName: "app",
+		Hostname:    "host1",
+		Fields:      Fields{"user": "bob", "attempt": 3, "at": tm},
+		GoroutineID: 7,
+		Stack:       "goroutine 7",
+	}
+
+	v, rest, err := decodeMsgpack([]byte((&MsgPackFormatter{}).Format(rec)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("%d trailing bytes", len(rest))
+	}
+	m := v.(map[string]interface{})
+	if ts, ok := m["time"].(time.Time); !ok || !ts.Equal(tm) {
+		t.Errorf("expected time %s got %v", tm, m["time"])
+	}
+	delete(m, "time")
+
+	expected := map[string]interface{}{
+		"level":       "ERROR",
+		"logger":      "web",
+		"message":     "request /index failed: timeout: dial",
+		"file":        "/src/app/main.go",
+		"line":        uint64(12),
+		"function":    "main.serve",
+		"host":        "host1",
+		"pid":         uint64(1234),
+		"process":     "app",
+		"goroutine":   uint64(7),
+		"stack":       "goroutine 7",
+		"error":       "timeout: dial",
+		"error_chain": []interface{}{"dial"},
+		"fields":      map[string]interface{}{"user": "bob", "attempt": uint64(3)},
+	}
+	fields := m["fields"].(map[string]interface{})
+	if at, ok := fields["at"].(time.Time); !ok || !at.Equal(tm) {
+		t.Errorf("expected field time %s got %v", tm, fields["at"])
+	}
+	delete(fields, "at")
+	if !reflect.DeepEqual(m, expected) {
+		t.Errorf("expected %#v got %#v", expected, m)
+	}
+}
+
+func TestMsgPackFormatter_Minimal(t *testing.T) {
+	rec := &Record{Format: "message", Level: INFO, Filename: "/src/app/main.go", Line: 1}
+
+	// Handlers trim the trailing newlines of messages before adding one.
+	out := strings.TrimRight((&MsgPackFormatter{DisableCaller: true}).Format(rec), "\n")
+	v, _, err := decodeMsgpack([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := v.(map[string]interface{})
+	for _, key := range []string{"file", "line", "function", "host", "goroutine", "stack", "error", "fields"} {
+		if _, ok := m[key]; ok {
+			t.Errorf("expected no %q got %v", key, m[key])
+		}
+	}
+	if m["message"] != "message" || m["level"] != "INFO" {
+		t.Errorf("unexpected map %v", m)
+	}
+}
+
+func TestAppendMsgpack_Time(t *testing.T) {
+	tm := time.Unix(1500000000, 123456789)
+	if b := appendMsgpack(nil, tm); b[0] != 0xd7 || b[1] != 0xff {
+		t.Errorf("expected the standard timestamp extension got %x", b)
+	}
+	if b := appendMsgpackEventTime(nil, tm); b[0] != 0xd7 || b[1] != 0x00 {
+		t.Errorf("expected the EventTime extension got %x", b)
+	}
+}
+
+func TestAppendMsgpackTimestamp(t *testing.T) {
+	for _, tm := range []time.Time{
+		time.Unix(0, 0),
+		time.Unix(1500000000, 123456789),
+		time.Unix(1<<34, 1),
+		time.Unix(-1, 999999999),
+		{},
+	} {
+		v, rest, err := decodeMsgpack(appendMsgpackTimestamp(nil, tm))
+		if err != nil {
+			t.Errorf("%s: %s", tm, err)
+			continue
+		}
+		if ts, ok := v.(time.Time); !ok || !ts.Equal(tm) || len(rest) != 0 {
+			t.Errorf("expected %s got %v", tm, v)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package logger
+
+// NullHandler is a handler implementation discarding every record.
+type NullHandler struct{}
+
+// DiscardHandler holds a handler discarding every record, e.g. to disable
+// the output of a logger.
+var DiscardHandler Handler = NullHandler{}
+
+// SetFormatter does nothing.
+func (NullHandler) SetFormatter(Formatter) {}
+
+// SetLevel does nothing.
+func (NullHandler) SetLevel(level) {}
+
+// Handle discards the record.
+func (NullHandler) Handle(*Record) error { return nil }
+
+// Flush does nothing.
+func (NullHandler) Flush() error { return nil }
+
+// Close does nothing.
+func (NullHandler) Close() {}
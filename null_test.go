@@ -0,0 +1,35 @@
+package logger
+
+import "testing"
+
+func TestDiscardHandler(t *testing.T) {
+	var hooked error
+	l := NewLogger("discard")
+	l.SetLevel(DEBUG)
+	l.SetHandler(DiscardHandler)
+	l.SetErrorHook(func(err error) { hooked = err })
+
+	for i := 0; i < 10000; i++ {
+		l.Debug("discarded %d", i)
+	}
+	if hooked != nil {
+		t.Errorf("unexpected error %s", hooked)
+	}
+
+	DiscardHandler.SetLevel(ERROR)
+	DiscardHandler.SetFormatter(&JSONFormatter{})
+	if err := DiscardHandler.Flush(); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+	DiscardHandler.Close()
+}
+
+func BenchmarkDiscardHandler(b *testing.B) {
+	l := NewLogger("discard")
+	l.SetHandler(DiscardHandler)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("benchmark %d", i)
+	}
+}
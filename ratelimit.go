@@ -0,0 +1,203 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RateLimitHandler passes at most Limit records per interval to the inner
+// handler for each logger name and drops the excess, so one noisy logger
+// does not starve the others.
+//
+// When an interval ends after records were dropped, a warning record
+// reporting the number of suppressed records is sent, even if the logger is
+// idle. Pending reports are sent on Close.
+type RateLimitHandler struct {
+	inner    Handler
+	limit    int
+	interval time.Duration
+	now      func() time.Time
+
+	reportMu   sync.Mutex // reportMu orders the reports at the end of the intervals with Close
+	mu         sync.Mutex
+	buckets    map[string]*rateBucket
+	swept      time.Time // swept holds the time the expired buckets were last evicted
+	suppressed uint64
+	closed     bool
+}
+
+// rateBucket counts the records of a logger in the current interval.
+type rateBucket struct {
+	start      time.Time
+	count      int
+	suppressed int
+	timer      *time.Timer // timer reports the suppressed records at the end of the interval
+}
+
+// NewRateLimitHandler creates a new rate limiting handler passing at most
+// limit records per interval and logger name to inner.
+func NewRateLimitHandler(inner Handler, limit int, interval time.Duration) *RateLimitHandler {
+	return &RateLimitHandler{
+		inner:    inner,
+		limit:    limit,
+		interval: interval,
+		now:      time.Now,
+		buckets:  make(map[string]*rateBucket),
+	}
+}
+
+// SetLevel sets logger level for inner handler.
+func (h *RateLimitHandler) SetLevel(l level) {
+	h.inner.SetLevel(l)
+}
+
+// SetFormatter sets logger formatter for inner handler.
+func (h *RateLimitHandler) SetFormatter(f Formatter) {
+	h.inner.SetFormatter(f)
+}
+
+// Handle passes rec to the inner handler unless the limit of its logger is reached.
+func (h *RateLimitHandler) Handle(rec *Record) error {
+	now := h.now()
+
+	h.mu.Lock()
+	if now.Sub(h.swept) >= h.interval {
+		h.sweep(now)
+	}
+	b, ok := h.buckets[rec.LoggerName]
+	if !ok {
+		b = &rateBucket{start: now}
+		h.buckets[rec.LoggerName] = b
+	}
+
+	var suppressed int
+	if now.Sub(b.start) >= h.interval {
+		if b.timer != nil {
+			b.timer.Stop()
+		}
+		suppressed = b.suppressed
+		*b = rateBucket{start: now}
+	}
+
+	allowed := b.count < h.limit
+	if allowed {
+		b.count++
+	} else {
+		b.suppressed++
+		h.suppressed++
+		if b.suppressed == 1 {
+			name, start := rec.LoggerName, b.start
+			b.timer = time.AfterFunc(start.Add(h.interval).Sub(now), func() {
+				h.report(name, b, start)
+			})
+		}
+	}
+	h.mu.Unlock()
+
+	var errs multiError
+	if suppressed > 0 {
+		errs.add(h.inner.Handle(suppressedRecord(rec.LoggerName, suppressed, now)))
+	}
+	if allowed {
+		errs.add(h.inner.Handle(rec))
+	}
+	return errs.err()
+}
+
+// sweep evicts the buckets whose interval ended without pending reports.
+// h.mu must be held.
+func (h *RateLimitHandler) sweep(now time.Time) {
+	for name, b := range h.buckets {
+		if b.suppressed == 0 && now.Sub(b.start) >= h.interval {
+			if b.timer != nil {
+				b.timer.Stop()
+			}
+			delete(h.buckets, name)
+		}
+	}
+	h.swept = now
+}
+
+// report sends the summary of the records of the logger suppressed in the
+// interval beginning at start, unless it was already sent. Failures are
+// reported on stderr.
+func (h *RateLimitHandler) report(name string, b *rateBucket, start time.Time) {
+	h.reportMu.Lock()
+	defer h.reportMu.Unlock()
+
+	h.mu.Lock()
+	var suppressed int
+	if !h.closed && b.start.Equal(start) {
+		suppressed = b.suppressed
+		b.suppressed = 0
+	}
+	h.mu.Unlock()
+
+	if suppressed > 0 {
+		if err := h.inner.Handle(suppressedRecord(name, suppressed, h.now())); err != nil {
+			fmt.Fprintf(os.Stderr, "RateLimitHandler can not report %d suppressed records: %s\n", suppressed, err)
+		}
+	}
+}
+
+// Suppressed reports the total number of records dropped by the handler.
+func (h *RateLimitHandler) Suppressed() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.suppressed
+}
+
+// Flush flushes the inner handler.
+func (h *RateLimitHandler) Flush() error {
+	return h.inner.Flush()
+}
+
+// Close reports the pending suppressed records and closes the inner handler.
+func (h *RateLimitHandler) Close() {
+	now := h.now()
+
+	h.reportMu.Lock()
+	defer h.reportMu.Unlock()
+
+	h.mu.Lock()
+	h.closed = true
+	names := make([]string, 0, len(h.buckets))
+	for name := range h.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var recs []*Record
+	for _, name := range names {
+		b := h.buckets[name]
+		if b.timer != nil {
+			b.timer.Stop()
+		}
+		if b.suppressed > 0 {
+			recs = append(recs, suppressedRecord(name, b.suppressed, now))
+			b.suppressed = 0
+		}
+	}
+	h.mu.Unlock()
+
+	for _, rec := range recs {
+		h.inner.Handle(rec)
+	}
+	h.inner.Close()
+}
+
+// suppressedRecord returns a warning record reporting n suppressed records of the logger.
+func suppressedRecord(name string, n int, t time.Time) *Record {
+	return &Record{
+		Format:      "suppressed %d records\n",
+		Args:        []interface{}{n},
+		LoggerName:  name,
+		Level:       WARNING,
+		Time:        t,
+		ProcessID:   pid,
+		ProcessName: pname,
+	}
+}
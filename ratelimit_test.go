@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRateLimitHandler_Handle(t *testing.T) {
+	r := NewLogRecorder()
+	h := NewRateLimitHandler(r, 3, time.Second)
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	for i := 0; i < 10; i++ {
+		h.Handle(&Record{LoggerName: "noisy", Format: "noisy %d", Args: []interface{}{i}})
+	}
+	h.Handle(&Record{LoggerName: "quiet", Format: "quiet"})
+
+	if n := len(r.Records["noisy"]); n != 3 {
+		t.Errorf("expected 3 records to pass got %d", n)
+	}
+	if n := len(r.Records["quiet"]); n != 1 {
+		t.Errorf("expected the quiet logger not to be throttled got %d records", n)
+	}
+	if n := h.Suppressed(); n != 7 {
+		t.Errorf("expected 7 suppressed records got %d", n)
+	}
+
+	now = now.Add(time.Second)
+	h.Handle(&Record{LoggerName: "noisy", Format: "next"})
+
+	recs := r.Records["noisy"]
+	if len(recs) != 5 {
+		t.Fatalf("expected a summary and the next record got %d records", len(recs))
+	}
+	if msg := recs[3].Message(); recs[3].Level != WARNING || msg != "suppressed 7 records" {
+		t.Errorf("unexpected summary %s %q", recs[3].Level, msg)
+	}
+	if msg := recs[4].Message(); msg != "next" {
+		t.Errorf("unexpected record %q", msg)
+	}
+}
+
+func TestRateLimitHandler_Close(t *testing.T) {
+	r := NewLogRecorder()
+	h := NewRateLimitHandler(r, 1, time.Hour)
+
+	h.Handle(&Record{LoggerName: "noisy", Format: "first"})
+	h.Handle(&Record{LoggerName: "noisy", Format: "second"})
+	h.Close()
+
+	recs := r.Records["noisy"]
+	if len(recs) != 2 || recs[1].Message() != "suppressed 1 records" {
+		t.Errorf("expected the pending summary on close got %v", recs)
+	}
+	if !r.Closed {
+		t.Errorf("inner handler is not closed")
+	}
+}
+
+func TestRateLimitHandler_Idle(t *testing.T) {
+	ch := make(chan *Record, 10)
+	h := NewRateLimitHandler(NewChannelHandler(ch, nil), 1, 20*time.Millisecond)
+	defer h.Close()
+
+	for i := 0; i < 3; i++ {
+		h.Handle(&Record{LoggerName: "noisy", Format: "record"})
+	}
+	if rec := <-ch; rec.Message() != "record" {
+		t.Fatalf("unexpected record %q", rec.Message())
+	}
+
+	select {
+	case rec := <-ch:
+		if rec.Level != WARNING || rec.Message() != "suppressed 2 records" {
+			t.Errorf("unexpected summary %s %q", rec.Level, rec.Message())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the summary at the end of the interval without a new record")
+	}
+}
+
+func TestRateLimitHandler_Evict(t *testing.T) {
+	h := NewRateLimitHandler(NewLogRecorder(), 1, time.Second)
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	for i := 0; i < 100; i++ {
+		h.Handle(&Record{LoggerName: fmt.Sprint("logger ", i), Format: "record"})
+	}
+	now = now.Add(time.Second)
+	h.Handle(&Record{LoggerName: "next", Format: "record"})
+
+	if n := len(h.buckets); n != 1 {
+		t.Errorf("expected the expired buckets to be evicted got %d buckets", n)
+	}
+}
@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the secrets masked by RedactingFormatter.
+const Redacted = "****"
+
+// RedactingFormatter masks secrets in the records formatted by another
+// formatter. The values of the structured fields named in Keys are replaced
+// before formatting, and the substrings of the output matched by Patterns
+// after it.
+type RedactingFormatter struct {
+	// Base formats the records. DefaultFormatter is used when it is nil.
+	Base Formatter
+
+	// Patterns match the secrets of the output, e.g. `Bearer (\S+)`. When a
+	// pattern has groups only the text they match is masked, otherwise the
+	// whole match is.
+	Patterns []*regexp.Regexp
+
+	// Keys are the names of the structured fields whose values are masked,
+	// compared without case.
+	Keys []string
+}
+
+// NewRedactingFormatter creates a new formatter masking the output of base
+// matched by patterns and the values of the fields named keys.
+func NewRedactingFormatter(base Formatter, patterns []*regexp.Regexp, keys ...string) *RedactingFormatter {
+	return &RedactingFormatter{Base: base, Patterns: patterns, Keys: keys}
+}
+
+func (f *RedactingFormatter) Format(rec *Record) string {
+	base := f.Base
+	if base == nil {
+		base = DefaultFormatter
+	}
+
+	if fields, ok := f.redactFields(rec.Fields); ok {
+		c := *rec
+		c.Fields = fields
+		rec = &c
+	}
+
+	s := base.Format(rec)
+	for _, re := range f.Patterns {
+		s = redact(re, s)
+	}
+	return s
+}
+
+// redactFields returns a copy of fields with the values of Keys masked, and
+// false if none of them is present.
+func (f *RedactingFormatter) redactFields(fields Fields) (Fields, bool) {
+	var redacted Fields
+	for k := range fields {
+		if !f.secretKey(k) {
+			continue
+		}
+		if redacted == nil {
+			redacted = make(Fields, len(fields))
+			for k, v := range fields {
+				redacted[k] = v
+			}
+		}
+		redacted[k] = Redacted
+	}
+	return redacted, redacted != nil
+}
+
+// secretKey reports whether the field named k is masked.
+func (f *RedactingFormatter) secretKey(k string) bool {
+	for _, key := range f.Keys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact returns s with the matches of re masked, or the text matched by
+// the groups of re if it has any.
+func redact(re *regexp.Regexp, s string) string {
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return s
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		spans := m[:2]
+		if len(m) > 2 {
+			spans = m[2:]
+		}
+		for i := 0; i < len(spans); i += 2 {
+			start, end := spans[i], spans[i+1]
+			if start < last {
+				// The group did not participate in the match, or is
+				// nested in a group already masked.
+				continue
+			}
+			b.WriteString(s[last:start])
+			b.WriteString(Redacted)
+			last = end
+		}
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRedactingFormatter_Patterns(t *testing.T) {
+	bearer := regexp.MustCompile(`Bearer (\S+)`)
+	apiKey := regexp.MustCompile(`sk_live_[0-9a-z]+`)
+	f := NewRedactingFormatter(&TextFormatter{}, []*regexp.Regexp{bearer, apiKey})
+
+	rec := &Record{
+		Format:   "request with Authorization: Bearer eyJhbGciOi.J9 and key sk_live_4f2a, retry Bearer abc",
+		Level:    INFO,
+		Time:     time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		Filename: "main.go",
+		Line:     1,
+	}
+
+	expected := "2021-03-04 05:06:07 INFO    [main.go:1] request with Authorization: Bearer **** and key ****, retry Bearer ****"
+	if out := f.Format(rec); out != expected {
+		t.Errorf("expected %q got %q", expected, out)
+	}
+}
+
+func TestRedactingFormatter_Keys(t *testing.T) {
+	fields := Fields{"user": "bob", "password": "hunter2", "API_Token": "abc"}
+	rec := &Record{
+		Format:     "login",
+		LoggerName: "auth",
+		Level:      INFO,
+		Time:       time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC),
+		Fields:     fields,
+	}
+
+	out := NewRedactingFormatter(&LogfmtFormatter{}, nil, "password", "api_token").Format(rec)
+	if expected := "msg=login API_Token=**** password=**** user=bob"; !strings.HasSuffix(out, expected) {
+		t.Errorf("expected suffix %q got %q", expected, out)
+	}
+	if strings.Contains(out, "hunter2") {
+		t.Errorf("expected the password to be masked got %q", out)
+	}
+	if fields["password"] != "hunter2" {
+		t.Errorf("expected the record fields to be left untouched")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		input    string
+		expected string
+	}{
+		{`secret`, "no match", "no match"},
+		{`\d{4}`, "card 1234 5678", "card **** ****"},
+		{`(user)=(\w+)`, "user=bob", "****=****"},
+		{`token=(\w+)|key=(\w+)`, "token=a key=b", "token=**** key=****"},
+		{`pass(word)?=(\w+)`, "pass=x", "pass=****"},
+	}
+	for _, test := range tests {
+		if out := redact(regexp.MustCompile(test.pattern), test.input); out != test.expected {
+			t.Errorf("%s: expected %q got %q", test.pattern, test.expected, out)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotatingFileHandler is a handler implementation that writes the logger
+// output to a file and rotates it once it grows beyond MaxBytes.
+//
+// On rotation the current file is renamed to path.1, existing backups are
+// shifted up by one (path.1 to path.2 and so on) and the backups beyond
+// BackupCount are removed. With Compress set path.1 is gzipped to path.1.gz
+// in the background.
+type RotatingFileHandler struct {
+	*BaseHandler
+
+	// MaxBytes is the size threshold of the log file. Zero disables rotation.
+	MaxBytes int64
+
+	// BackupCount is the number of rotated files to keep. When it is zero
+	// the log file is truncated on rotation.
+	BackupCount int
+
+	// Compress enables gzipping the backups.
+	Compress bool
+
+	mu          sync.Mutex
+	path        string
+	file        *os.File
+	size        int64          // size holds the current size of the log file
+	compressing sync.WaitGroup // compressing tracks the running compression
+}
+
+// NewRotatingFileHandler creates a new rotating file handler appending to the file at path.
+func NewRotatingFileHandler(path string, maxBytes int64, backupCount int) (*RotatingFileHandler, error) {
+	h := &RotatingFileHandler{
+		BaseHandler: NewBaseHandler(),
+		MaxBytes:    maxBytes,
+		BackupCount: backupCount,
+		path:        path,
+	}
+	if err := h.open(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Handle writes the formatted record to the file, rotating it first if the
+// record would exceed MaxBytes.
+// A failed rotation is reported once the record is written to the current
+// file, if it could be reopened.
+func (h *RotatingFileHandler) Handle(rec *Record) error {
+	message := h.BaseHandler.FilterAndFormat(rec)
+	if message == "" {
+		return nil
+	}
+	message = line(message)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var rotateErr error
+	if h.shouldRotate(len(message)) {
+		if err := h.rotate(); err != nil {
+			rotateErr = fmt.Errorf("RotatingFileHandler can not rotate %s: %s", h.path, err)
+			if h.file == nil {
+				return rotateErr
+			}
+		}
+	}
+
+	n, err := h.file.WriteString(message)
+	h.size += int64(n)
+	if err != nil {
+		return err
+	}
+	return rotateErr
+}
+
+// Close closes the current log file and waits for the running compression.
+func (h *RotatingFileHandler) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file != nil {
+		h.file.Close()
+		h.file = nil
+	}
+	h.compressing.Wait()
+}
+
+// shouldRotate reports whether writing n more bytes would exceed MaxBytes.
+// A record larger than MaxBytes is written to an empty file without rotating.
+func (h *RotatingFileHandler) shouldRotate(n int) bool {
+	return h.MaxBytes > 0 && h.size > 0 && h.size+int64(n) > h.MaxBytes
+}
+
+// rotate closes the current file, shifts the backups and opens a new file.
+func (h *RotatingFileHandler) rotate() error {
+	h.file.Close()
+	h.file = nil
+
+	if h.BackupCount > 0 {
+		// The backups can not be shifted while path.1 is compressed.
+		h.compressing.Wait()
+
+		for _, ext := range backupExts {
+			os.Remove(h.backupName(h.BackupCount) + ext)
+		}
+		for i := h.BackupCount - 1; i > 0; i-- {
+			for _, ext := range backupExts {
+				os.Rename(h.backupName(i)+ext, h.backupName(i+1)+ext)
+			}
+		}
+		if err := os.Rename(h.path, h.backupName(1)); err != nil {
+			h.open()
+			return err
+		}
+	} else if err := os.Truncate(h.path, 0); err != nil {
+		h.open()
+		return err
+	}
+
+	if err := h.open(); err != nil {
+		return err
+	}
+
+	if h.Compress && h.BackupCount > 0 {
+		h.compressing.Add(1)
+		go func(name string) {
+			defer h.compressing.Done()
+			if err := compressFile(name); err != nil {
+				fmt.Fprintf(os.Stderr, "RotatingFileHandler can not compress %s: %s\n", name, err)
+			}
+		}(h.backupName(1))
+	}
+	return nil
+}
+
+// backupExts holds the extensions of plain and compressed backups.
+var backupExts = []string{"", ".gz"}
+
+// compressFile gzips the file at name to name.gz and removes the original.
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(name + ".gz")
+		return err
+	}
+	return os.Remove(name)
+}
+
+// open opens the log file and records its current size.
+func (h *RotatingFileHandler) open() error {
+	f, err := openLogFile(h.path)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	h.file = f
+	h.size = fi.Size()
+	return nil
+}
+
+// backupName returns the name of the i-th backup file.
+func (h *RotatingFileHandler) backupName(i int) string {
+	return fmt.Sprintf("%s.%d", h.path, i)
+}
+
+// RotationInterval is the wall-clock interval of a TimedRotatingFileHandler.
+type RotationInterval int
+
+// Rotation intervals.
+const (
+	Hourly RotationInterval = iota
+	Daily
+)
+
+// layout returns the time layout of the backup file suffix.
+func (i RotationInterval) layout() string {
+	if i == Hourly {
+		return "2006-01-02_15"
+	}
+	return "2006-01-02"
+}
+
+// start returns the beginning of the interval containing t.
+func (i RotationInterval) start(t time.Time) time.Time {
+	y, m, d := t.Date()
+	hour := 0
+	if i == Hourly {
+		hour = t.Hour()
+	}
+	return time.Date(y, m, d, hour, 0, 0, 0, t.Location())
+}
+
+// TimedRotatingFileHandler is a handler implementation that writes the logger
+// output to a file and rotates it on a wall-clock interval.
+//
+// Rotation is driven by the time of the records: when a record belongs to a
+// later interval than the current file, the file is renamed with the start
+// of its interval as suffix, e.g. app.log.2024-01-02, and a new file is
+// opened. Only the newest BackupCount rotated files are kept.
+type TimedRotatingFileHandler struct {
+	*BaseHandler
+
+	// BackupCount is the number of rotated files to keep. Zero keeps all of them.
+	BackupCount int
+
+	mu       sync.Mutex
+	path     string
+	interval RotationInterval
+	file     *os.File
+	period   time.Time // period holds the start of the current file's interval
+}
+
+// NewTimedRotatingFileHandler creates a new timed rotating file handler appending to the file at path.
+func NewTimedRotatingFileHandler(path string, interval RotationInterval, backupCount int) (*TimedRotatingFileHandler, error) {
+	f, err := openLogFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	h := &TimedRotatingFileHandler{
+		BaseHandler: NewBaseHandler(),
+		BackupCount: backupCount,
+		path:        path,
+		interval:    interval,
+		file:        f,
+	}
+
+	// An existing file belongs to the interval it was last written in.
+	if fi, err := f.Stat(); err == nil && fi.Size() > 0 {
+		h.period = interval.start(fi.ModTime())
+	}
+
+	return h, nil
+}
+
+// Handle writes the formatted record to the file, rotating it first if the
+// record belongs to a later interval.
+func (h *TimedRotatingFileHandler) Handle(rec *Record) error {
+	message := h.BaseHandler.FilterAndFormat(rec)
+	if message == "" {
+		return nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var rotateErr error
+	period := h.interval.start(rec.Time)
+	if h.period.IsZero() {
+		h.period = period
+	} else if period.After(h.period) {
+		if err := h.rotate(); err != nil {
+			rotateErr = fmt.Errorf("TimedRotatingFileHandler can not rotate %s: %s", h.path, err)
+			if h.file == nil {
+				return rotateErr
+			}
+		}
+		h.period = period
+	}
+
+	if _, err := h.file.WriteString(line(message)); err != nil {
+		return err
+	}
+	return rotateErr
+}
+
+// Close closes the current log file.
+func (h *TimedRotatingFileHandler) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file != nil {
+		h.file.Close()
+		h.file = nil
+	}
+}
+
+// rotate renames the current file after its interval, opens a new file and
+// removes the backups beyond BackupCount.
+func (h *TimedRotatingFileHandler) rotate() (err error) {
+	h.file.Close()
+	h.file = nil
+
+	backup := h.path + "." + h.period.Format(h.interval.layout())
+	if err := os.Rename(h.path, backup); err != nil {
+		h.file, _ = openLogFile(h.path)
+		return err
+	}
+
+	if h.file, err = openLogFile(h.path); err != nil {
+		return err
+	}
+
+	if h.BackupCount > 0 {
+		backups := h.backups()
+		for i := 0; i < len(backups)-h.BackupCount; i++ {
+			os.Remove(backups[i])
+		}
+	}
+	return nil
+}
+
+// backups returns the rotated files of the handler, oldest first.
+func (h *TimedRotatingFileHandler) backups() []string {
+	matches, _ := filepath.Glob(h.path + ".*")
+
+	var backups []string
+	for _, name := range matches {
+		if _, err := time.Parse(h.interval.layout(), strings.TrimPrefix(name, h.path+".")); err == nil {
+			backups = append(backups, name)
+		}
+	}
+	sort.Strings(backups)
+	return backups
+}
@@ -0,0 +1,162 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// messageFormatter formats records as their bare message.
+type messageFormatter struct{}
+
+func (f *messageFormatter) Format(rec *Record) string {
+	return rec.Message()
+}
+
+func TestRotatingFileHandler_Handle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	h, err := NewRotatingFileHandler(path, 100, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.SetFormatter(&messageFormatter{})
+
+	l := NewLogger("rotating")
+	l.SetHandler(h)
+
+	record := strings.Repeat("x", 39) // 40 bytes with the newline
+	for i := 0; i < 7; i++ {
+		l.Info(record)
+	}
+	h.Close()
+
+	expected := map[string]int64{
+		path:        40,
+		path + ".1": 80,
+		path + ".2": 80,
+	}
+	for name, size := range expected {
+		fi, err := os.Stat(name)
+		if err != nil {
+			t.Errorf("missing file: %s", err)
+			continue
+		}
+		if fi.Size() != size {
+			t.Errorf("expected %s to have %d bytes got %d", name, size, fi.Size())
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected backups beyond BackupCount to be removed")
+	}
+}
+
+func TestRotatingFileHandler_Compress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	h, err := NewRotatingFileHandler(path, 100, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Compress = true
+	h.SetFormatter(&messageFormatter{})
+
+	record := func(i int) string {
+		return strings.Repeat(string(rune('a'+i)), 39) + "\n"
+	}
+	for i := 0; i < 7; i++ {
+		h.Handle(&Record{Format: record(i), Level: INFO})
+	}
+	h.Close()
+
+	expected := map[string]string{
+		path + ".1.gz": record(4) + record(5),
+		path + ".2.gz": record(2) + record(3),
+	}
+	for name, content := range expected {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Errorf("missing file: %s", err)
+			continue
+		}
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			t.Errorf("invalid gzip file %s: %s", name, err)
+			f.Close()
+			continue
+		}
+		b, err := ioutil.ReadAll(zr)
+		f.Close()
+		if err != nil {
+			t.Errorf("invalid gzip file %s: %s", name, err)
+		} else if string(b) != content {
+			t.Errorf("unexpected content of %s: %q", name, b)
+		}
+	}
+
+	for _, name := range []string{path + ".1", path + ".2", path + ".3.gz"} {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed", name)
+		}
+	}
+	if b, _ := ioutil.ReadFile(path); string(b) != record(6) {
+		t.Errorf("unexpected content of %s: %q", path, b)
+	}
+}
+
+func TestTimedRotatingFileHandler_Handle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	h, err := NewTimedRotatingFileHandler(path, Daily, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.SetFormatter(&messageFormatter{})
+
+	day := time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC)
+	for i, hours := range []int{0, 0, 2, 26, 50} {
+		h.Handle(&Record{
+			Format: fmt.Sprint("record ", i),
+			Level:  INFO,
+			Time:   day.Add(time.Duration(hours) * time.Hour),
+		})
+	}
+	h.Close()
+
+	expected := map[string]string{
+		path:                 "record 4\n",
+		path + ".2024-01-03": "record 3\n",
+		path + ".2024-01-02": "record 2\n",
+	}
+	for name, content := range expected {
+		b, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Errorf("missing file: %s", err)
+			continue
+		}
+		if string(b) != content {
+			t.Errorf("expected %s to contain %q got %q", name, content, b)
+		}
+	}
+
+	if _, err := os.Stat(path + ".2024-01-01"); !os.IsNotExist(err) {
+		t.Errorf("expected backups beyond BackupCount to be removed")
+	}
+}
+
+func TestRotationInterval_Start(t *testing.T) {
+	tm := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	if s := Hourly.start(tm); !s.Equal(time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected hourly start %s", s)
+	}
+	if s := Daily.start(tm); !s.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected daily start %s", s)
+	}
+}
@@ -0,0 +1,105 @@
+package logger
+
+// LevelRouterHandler dispatches records to handlers by level.
+//
+// Each record is handled by every handler routed for its level. Records
+// matching no route go to the default handler, if any. Routes must be
+// registered before the handler is used.
+type LevelRouterHandler struct {
+	routes []levelRoute
+	def    Handler
+}
+
+// levelRoute routes the levels between min and max to handler.
+type levelRoute struct {
+	min, max level
+	handler  Handler
+}
+
+// NewLevelRouterHandler creates a new router handler falling through to
+// def for unrouted levels. def may be nil to drop such records.
+func NewLevelRouterHandler(def Handler) *LevelRouterHandler {
+	return &LevelRouterHandler{def: def}
+}
+
+// Route routes records of level l to handler.
+func (h *LevelRouterHandler) Route(l level, handler Handler) *LevelRouterHandler {
+	return h.RouteRange(l, l, handler)
+}
+
+// RouteRange routes records with levels between from and to, inclusive, to handler.
+func (h *LevelRouterHandler) RouteRange(from, to level, handler Handler) *LevelRouterHandler {
+	if from > to {
+		from, to = to, from
+	}
+	h.routes = append(h.routes, levelRoute{min: from, max: to, handler: handler})
+	return h
+}
+
+// SetFormatter sets formatter for all handlers
+func (h *LevelRouterHandler) SetFormatter(f Formatter) {
+	for _, handler := range h.handlers() {
+		handler.SetFormatter(f)
+	}
+}
+
+// SetLevel sets level for all handlers
+func (h *LevelRouterHandler) SetLevel(l level) {
+	for _, handler := range h.handlers() {
+		handler.SetLevel(l)
+	}
+}
+
+// Handle dispatches the record to the handlers routed for its level.
+func (h *LevelRouterHandler) Handle(rec *Record) error {
+	var errs multiError
+	routed := false
+	for _, r := range h.routes {
+		if rec.Level >= r.min && rec.Level <= r.max {
+			errs.add(r.handler.Handle(rec))
+			routed = true
+		}
+	}
+
+	if !routed && h.def != nil {
+		errs.add(h.def.Handle(rec))
+	}
+	return errs.err()
+}
+
+// Flush flushes all handlers.
+func (h *LevelRouterHandler) Flush() error {
+	var errs multiError
+	for _, handler := range h.handlers() {
+		errs.add(handler.Flush())
+	}
+	return errs.err()
+}
+
+// Close closes all handlers
+func (h *LevelRouterHandler) Close() {
+	for _, handler := range h.handlers() {
+		handler.Close()
+	}
+}
+
+// handlers returns the distinct handlers of the router including the default.
+func (h *LevelRouterHandler) handlers() []Handler {
+	var handlers []Handler
+	add := func(handler Handler) {
+		for _, existing := range handlers {
+			if existing == handler {
+				return
+			}
+		}
+		handlers = append(handlers, handler)
+	}
+
+	for _, r := range h.routes {
+		add(r.handler)
+	}
+	if h.def != nil {
+		add(h.def)
+	}
+	return handlers
+}
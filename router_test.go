@@ -0,0 +1,55 @@
+package logger
+
+import "testing"
+
+func TestLevelRouterHandler_Handle(t *testing.T) {
+	critical, errors, def := NewLogRecorder(), NewLogRecorder(), NewLogRecorder()
+	h := NewLevelRouterHandler(def).
+		Route(CRITICAL, critical).
+		RouteRange(WARNING, CRITICAL, errors)
+
+	l := NewLogger("router")
+	l.SetLevel(DEBUG)
+	l.SetHandler(h)
+
+	l.Critical("critical")
+	l.Error("error")
+	l.Warning("warning")
+	l.Info("info")
+	l.Debug("debug")
+
+	if n := len(critical.Records["router"]); n != 1 {
+		t.Errorf("expected 1 critical record got %d", n)
+	}
+	if n := len(errors.Records["router"]); n != 3 {
+		t.Errorf("expected 3 records between warning and critical got %d", n)
+	}
+	if n := len(def.Records["router"]); n != 2 {
+		t.Errorf("expected 2 unrouted records got %d", n)
+	}
+	for _, rec := range critical.Records["router"] {
+		if rec.Level != CRITICAL {
+			t.Errorf("unexpected %s record in critical handler", rec.Level)
+		}
+	}
+
+	h.SetLevel(ERROR)
+	h.Close()
+	for _, r := range []*LogRecorder{critical, errors, def} {
+		if r.Level != ERROR || !r.Closed {
+			t.Errorf("level and close are not propagated")
+		}
+	}
+}
+
+func TestLevelRouterHandler_NoDefault(t *testing.T) {
+	r := NewLogRecorder()
+	h := NewLevelRouterHandler(nil).Route(ERROR, r)
+
+	h.Handle(&Record{LoggerName: "router", Level: INFO})
+	h.Handle(&Record{LoggerName: "router", Level: ERROR})
+
+	if n := len(r.Records["router"]); n != 1 {
+		t.Errorf("expected 1 record got %d", n)
+	}
+}
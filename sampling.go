@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// SamplingHandler limits the volume of records per level and interval.
+//
+// In each interval the first records of a level are passed to the inner
+// handler, after which only one record in M is passed, like zap's sampler.
+// The record passed in each run of M is picked at random, so that periodic
+// records are not always dropped. The sampling parameters can be configured
+// per level.
+type SamplingHandler struct {
+	inner    Handler
+	interval time.Duration
+	now      func() time.Time
+
+	mu       sync.Mutex
+	rnd      *rand.Rand // rnd picks the sampled records, guarded by mu
+	def      sampling
+	levels   map[level]sampling
+	counters map[level]*sampleCounter
+}
+
+// sampling holds the sampling parameters of a level.
+type sampling struct {
+	first      int
+	thereafter int
+}
+
+// sampleCounter counts the records of a level in the current interval.
+type sampleCounter struct {
+	start time.Time
+	count int
+	pick  int // pick is the position of the passed record in the current run
+}
+
+// NewSamplingHandler creates a new sampling handler passing the first
+// records of each level per interval to inner, then one in thereafter.
+// A zero thereafter drops every record after the first ones.
+func NewSamplingHandler(inner Handler, interval time.Duration, first, thereafter int) *SamplingHandler {
+	return &SamplingHandler{
+		inner:    inner,
+		interval: interval,
+		now:      time.Now,
+		rnd:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		def:      sampling{first: first, thereafter: thereafter},
+		levels:   make(map[level]sampling),
+		counters: make(map[level]*sampleCounter),
+	}
+}
+
+// SetSampling overrides the sampling parameters of level l.
+func (h *SamplingHandler) SetSampling(l level, first, thereafter int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.levels[l] = sampling{first: first, thereafter: thereafter}
+}
+
+// SetRandSource replaces the time seeded source picking the sampled records,
+// e.g. with rand.NewSource(seed) for reproducible sampling in tests.
+func (h *SamplingHandler) SetRandSource(src rand.Source) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rnd = rand.New(src)
+}
+
+// SetLevel sets logger level for inner handler.
+func (h *SamplingHandler) SetLevel(l level) {
+	h.inner.SetLevel(l)
+}
+
+// SetFormatter sets logger formatter for inner handler.
+func (h *SamplingHandler) SetFormatter(f Formatter) {
+	h.inner.SetFormatter(f)
+}
+
+// Handle passes rec to the inner handler if it is sampled.
+func (h *SamplingHandler) Handle(rec *Record) error {
+	if h.sample(rec.Level) {
+		return h.inner.Handle(rec)
+	}
+	return nil
+}
+
+// Flush flushes the inner handler.
+func (h *SamplingHandler) Flush() error {
+	return h.inner.Flush()
+}
+
+// Close closes the inner handler.
+func (h *SamplingHandler) Close() {
+	h.inner.Close()
+}
+
+// sample counts a record of level l and reports whether it is passed.
+func (h *SamplingHandler) sample(l level) bool {
+	now := h.now()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c, ok := h.counters[l]
+	if !ok || now.Sub(c.start) >= h.interval {
+		c = &sampleCounter{start: now}
+		h.counters[l] = c
+	}
+	c.count++
+
+	s, ok := h.levels[l]
+	if !ok {
+		s = h.def
+	}
+
+	if c.count <= s.first {
+		return true
+	}
+	if s.thereafter <= 0 {
+		return false
+	}
+
+	pos := (c.count - s.first - 1) % s.thereafter
+	if pos == 0 {
+		c.pick = h.rnd.Intn(s.thereafter)
+	}
+	return pos == c.pick
+}
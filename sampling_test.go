@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestSamplingHandler_Handle(t *testing.T) {
+	r := NewLogRecorder()
+	h := NewSamplingHandler(r, time.Minute, 3, 5)
+	h.SetSampling(ERROR, 100, 0)
+
+	for i := 1; i <= 23; i++ {
+		h.Handle(&Record{LoggerName: "debug", Level: DEBUG, Args: []interface{}{i}})
+		h.Handle(&Record{LoggerName: "error", Level: ERROR, Args: []interface{}{i}})
+	}
+
+	recs := r.Records["debug"]
+	if len(recs) != 7 {
+		t.Fatalf("expected the first 3 then 1 in 5 records got %d", len(recs))
+	}
+	for i, rec := range recs {
+		n := rec.Args[0].(int)
+		if i < 3 && n != i+1 {
+			t.Errorf("expected record %d to pass got %d", i+1, n)
+		}
+		if from := 4 + (i-3)*5; i >= 3 && (n < from || n >= from+5) {
+			t.Errorf("expected a record in [%d, %d] got %d", from, from+4, n)
+		}
+	}
+	if n := len(r.Records["error"]); n != 23 {
+		t.Errorf("expected every error record to pass got %d", n)
+	}
+}
+
+func TestSamplingHandler_RandSource(t *testing.T) {
+	r := NewLogRecorder()
+	h := NewSamplingHandler(r, time.Minute, 3, 5)
+	h.SetRandSource(rand.NewSource(42))
+
+	for i := 1; i <= 23; i++ {
+		h.Handle(&Record{LoggerName: "sampling", Level: DEBUG, Args: []interface{}{i}})
+	}
+
+	var passed []interface{}
+	for _, rec := range r.Records["sampling"] {
+		passed = append(passed, rec.Args[0])
+	}
+	if s := fmt.Sprint(passed); s != "[1 2 3 4 11 17 19]" {
+		t.Errorf("expected the records sampled with seed 42 got %s", s)
+	}
+}
+
+func TestSamplingHandler_Interval(t *testing.T) {
+	r := NewLogRecorder()
+	h := NewSamplingHandler(r, time.Second, 1, 0)
+
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	h.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		h.Handle(&Record{LoggerName: "sampling", Level: INFO})
+		h.Handle(&Record{LoggerName: "sampling", Level: INFO})
+		now = now.Add(time.Second)
+	}
+
+	if n := len(r.Records["sampling"]); n != 3 {
+		t.Errorf("expected the first record of each interval got %d records", n)
+	}
+}
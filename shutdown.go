@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"os"
+	"sync"
+)
+
+var (
+	// exitFunc is called by Fatal to exit, replaced in tests.
+	exitFunc = os.Exit
+
+	// panicFunc is called by Panic to panic, replaced in tests.
+	panicFunc = func(v interface{}) { panic(v) }
+
+	shutdownMu sync.Mutex
+	exitFuncs  []func() // exitFuncs are run by Close in reverse order
+	handlers   []Handler
+)
+
+// RegisterHandler registers a handler to be flushed and closed by Close,
+// for handlers not attached to the DefaultLogger.
+func RegisterHandler(h Handler) {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+	handlers = append(handlers, h)
+}
+
+// AtExit registers a function to be run by Close, and so by Fatal, before
+// the handlers are closed. The functions run in the reverse order of their
+// registration.
+func AtExit(fn func()) {
+	shutdownMu.Lock()
+	defer shutdownMu.Unlock()
+	exitFuncs = append(exitFuncs, fn)
+}
+
+// Close runs the functions registered with AtExit, then flushes and closes
+// the handler of the DefaultLogger and the registered handlers. It is meant
+// to be called once, at program exit, e.g. deferred in main. It returns the
+// errors of the flushes.
+func Close() error {
+	return shutdown(handlerOf(DefaultLogger))
+}
+
+// shutdown runs the exit functions, then flushes and closes h and the
+// registered handlers, each once. The registrations are cleared.
+func shutdown(h Handler) error {
+	shutdownMu.Lock()
+	fns, hs := exitFuncs, handlers
+	exitFuncs, handlers = nil, nil
+	shutdownMu.Unlock()
+
+	for i := len(fns) - 1; i >= 0; i-- {
+		fns[i]()
+	}
+
+	if h != nil {
+		hs = append([]Handler{h}, hs...)
+	}
+
+	var errs multiError
+	for i, h := range hs {
+		if containsHandler(hs[:i], h) {
+			continue
+		}
+		errs.add(h.Flush())
+		h.Close()
+	}
+	return errs.err()
+}
+
+// containsHandler reports whether h is one of handlers.
+func containsHandler(handlers []Handler, h Handler) bool {
+	for _, existing := range handlers {
+		if existing == h {
+			return true
+		}
+	}
+	return false
+}
+
+// handlerOf returns the handler of a Logger of this package, or nil.
+func handlerOf(l Logger) Handler {
+	switch l := l.(type) {
+	case *logger:
+		return l.Handler
+	case *context:
+		return l.Handler
+	}
+	return nil
+}
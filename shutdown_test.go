@@ -0,0 +1,216 @@
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClose(t *testing.T) {
+	defer func(l Logger) { DefaultLogger = l }(DefaultLogger)
+
+	def, registered := NewLogRecorder(), NewLogRecorder()
+	DefaultLogger = NewLogger("close")
+	SetHandler(def)
+	RegisterHandler(registered)
+	RegisterHandler(def)
+
+	var order []int
+	AtExit(func() { order = append(order, 1) })
+	AtExit(func() { order = append(order, 2) })
+
+	if err := Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(order, []int{2, 1}) {
+		t.Errorf("expected exit functions in reverse order got %v", order)
+	}
+	for _, r := range []*LogRecorder{def, registered} {
+		if r.Flushed != 1 || !r.Closed {
+			t.Errorf("expected handler flushed and closed once got %d flushes, closed %v", r.Flushed, r.Closed)
+		}
+	}
+
+	// The registrations are cleared.
+	registered.Closed = false
+	if Close(); registered.Closed {
+		t.Errorf("expected registered handlers to be closed once")
+	}
+}
+
+func TestLogger_FatalCloses(t *testing.T) {
+	if path := os.Getenv("LOGGER_TEST_FATAL"); path != "" {
+		h, err := NewBufferedFileHandler(path, 4096, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l := NewLogger("fatal")
+		l.SetHandler(h)
+		AtExit(func() { l.Info("exiting") })
+		l.Fatal("fatal %d", 1)
+		return
+	}
+
+	path := filepath.Join(t.TempDir(), "fatal.log")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLogger_FatalCloses$")
+	cmd.Env = append(os.Environ(), "LOGGER_TEST_FATAL="+path)
+	err := cmd.Run()
+	if e, ok := err.(*exec.ExitError); !ok || e.ExitCode() != 1 {
+		t.Fatalf("expected exit status 1 got %v", err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "fatal 1") || !strings.HasSuffix(lines[1], "exiting") {
+		t.Errorf("expected the fatal message and the exit function output got %q", b)
+	}
+}
+
+func TestLogger_FatalFlushesSink(t *testing.T) {
+	if path := os.Getenv("LOGGER_TEST_FATAL_SINK"); path != "" {
+		f, err := NewFileHandler(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l := NewLogger("fatal")
+		l.SetHandler(NewSinkHandler(f, 100))
+		for i := 0; i < 50; i++ {
+			l.Info("queued %d", i)
+		}
+		l.Fatal("fatal")
+		return
+	}
+
+	path := filepath.Join(t.TempDir(), "fatal.log")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLogger_FatalFlushesSink$")
+	cmd.Env = append(os.Environ(), "LOGGER_TEST_FATAL_SINK="+path)
+	err := cmd.Run()
+	if e, ok := err.(*exec.ExitError); !ok || e.ExitCode() != 1 {
+		t.Fatalf("expected exit status 1 got %v", err)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 51 || !strings.HasSuffix(lines[50], "fatal") {
+		t.Errorf("expected the queued records then the fatal message got %d lines", len(lines))
+	}
+}
+
+func TestLogger_FatalExitFunc(t *testing.T) {
+	defer func(f func(int)) { exitFunc = f }(exitFunc)
+	code := -1
+	exitFunc = func(c int) { code = c }
+
+	r := NewLogRecorder()
+	l := NewLogger("exit")
+	l.SetHandler(r)
+	l.Fatal("fatal %s", "error")
+
+	if code != 1 {
+		t.Errorf("expected exit code 1 got %d", code)
+	}
+	recs := r.Records["exit"]
+	if len(recs) != 1 || recs[0].Level != CRITICAL || recs[0].Message() != "fatal error" {
+		t.Errorf("expected the critical fatal record got %v", recs)
+	}
+	if r.Flushed != 1 || !r.Closed {
+		t.Errorf("expected the handler to be flushed and closed before exiting")
+	}
+}
+
+func TestLogger_FatalWithCode(t *testing.T) {
+	defer func(f func(int)) { exitFunc = f }(exitFunc)
+	code := -1
+	exitFunc = func(c int) { code = c }
+
+	r := NewLogRecorder()
+	l := NewLogger("exit").WithPrefix("db")
+	l.SetHandler(r)
+	l.FatalWithCode(3, "can not connect")
+
+	if code != 3 {
+		t.Errorf("expected exit code 3 got %d", code)
+	}
+	recs := r.Records["exit"]
+	if len(recs) != 1 || recs[0].Level != CRITICAL || recs[0].Message() != "[db] can not connect" {
+		t.Errorf("expected the prefixed critical record got %v", recs)
+	}
+	if !r.Closed {
+		t.Errorf("expected the handler to be closed before exiting")
+	}
+}
+
+func TestLogger_PanicFunc(t *testing.T) {
+	defer func(f func(interface{})) { panicFunc = f }(panicFunc)
+	var value interface{}
+	panicFunc = func(v interface{}) { value = v }
+
+	r := NewLogRecorder()
+	l := NewLogger("panicfunc")
+	l.SetHandler(r)
+	l.Panic("boom %d", 42)
+
+	if value != "boom 42" {
+		t.Errorf("expected panic value %q got %v", "boom 42", value)
+	}
+	if n := len(r.Records["panicfunc"]); n != 1 {
+		t.Errorf("expected 1 record got %d", n)
+	}
+}
+
+func TestLogger_PanicFields(t *testing.T) {
+	defer func(f func(interface{})) { panicFunc = f }(panicFunc)
+	var value interface{}
+	panicFunc = func(v interface{}) { value = v }
+
+	r := NewLogRecorder()
+	l := NewLogger("panicfunc")
+	l.SetHandler(r)
+	l.Panic("request %s failed", "a", Fields{"k": 1})
+
+	if value != "request a failed" {
+		t.Errorf("expected panic value %q got %v", "request a failed", value)
+	}
+	if recs := r.Records["panicfunc"]; len(recs) != 1 || recs[0].Fields["k"] != 1 {
+		t.Errorf("expected the fields on the record got %v", recs)
+	}
+}
+
+func TestLogger_PanicLazyArgs(t *testing.T) {
+	defer func(f func(interface{})) { panicFunc = f }(panicFunc)
+	var value interface{}
+	panicFunc = func(v interface{}) { value = v }
+
+	calls := 0
+	lazy := func() interface{} {
+		calls++
+		return "computed"
+	}
+
+	r := NewLogRecorder()
+	l := NewLogger("panicfunc")
+	l.SetHandler(r)
+	l.Panic("value %v", lazy)
+
+	if value != "value computed" {
+		t.Errorf("expected panic value %q got %v", "value computed", value)
+	}
+	if calls != 1 {
+		t.Errorf("expected the lazy argument to be computed once got %d calls", calls)
+	}
+	if recs := r.Records["panicfunc"]; len(recs) != 1 || recs[0].Message() != "value computed" {
+		t.Errorf("expected the computed value in the record got %v", recs)
+	}
+}
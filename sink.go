@@ -1,27 +1,76 @@
 package logger
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy decides what SinkHandler does with a record when its buffer is full.
+type OverflowPolicy int
+
+// Overflow policies.
+const (
+	// DropNewest drops the incoming record with a warning on stderr.
+	DropNewest OverflowPolicy = iota
+
+	// Block waits until there is room in the buffer.
+	Block
+
+	// DropOldest drops the oldest buffered record to make room for the incoming one.
+	DropOldest
 )
 
 // SinkHandler sends log records to buffered channel, the logs are written in a dedicated routine consuming the channel.
 type SinkHandler struct {
+	dropped uint64 // dropped counts dropped records, kept first for 64-bit alignment
 	inner   Handler
-	sinkCh  chan *Record
-	bufSize int
-	wg      sync.WaitGroup
+	policy  OverflowPolicy
+	done    chan struct{} // done is closed when all the records are processed
+
+	chMu      sync.RWMutex // chMu guards sinkCh, write locked to swap or close it
+	sinkCh    chan *Record
+	bufSize   int
+	nextCh    chan chan *Record // nextCh passes the channel replacing a closed one to process
+	closed    bool              // closed is set once the handler is closed
+	closeOnce sync.Once
+
+	// OnError receives the errors of the inner handler, including its
+	// panics. They are printed to stderr if it is nil. It must be set
+	// before the handler is used.
+	OnError func(error)
+
+	mu      sync.Mutex
+	drained *sync.Cond // drained is signaled when no record is pending
+	pending int        // pending counts the records queued or being handled
 }
 
+var _ Handler = (*SinkHandler)(nil)
+
+// NewSinkHandler creates a new sink handler dropping incoming records when the buffer is full.
 func NewSinkHandler(inner Handler, bufSize int) *SinkHandler {
+	return NewSinkHandlerWithPolicy(inner, bufSize, DropNewest)
+}
+
+// NewSinkHandlerWithPolicy creates a new sink handler handling a full buffer according to policy.
+// A nil inner handler discards the records.
+func NewSinkHandlerWithPolicy(inner Handler, bufSize int, policy OverflowPolicy) *SinkHandler {
+	if inner == nil {
+		inner = DiscardHandler
+	}
 	b := &SinkHandler{
 		inner:   inner,
 		sinkCh:  make(chan *Record, bufSize),
 		bufSize: bufSize,
+		nextCh:  make(chan chan *Record, 1),
+		policy:  policy,
+		done:    make(chan struct{}),
 	}
+	b.drained = sync.NewCond(&b.mu)
 
-	b.wg.Add(1)
 	go b.process()
 
 	return b
@@ -29,23 +78,84 @@ func NewSinkHandler(inner Handler, bufSize int) *SinkHandler {
 
 // process reads log records from sinkCh and calls inner log handler to write it.
 func (b *SinkHandler) process() {
+	b.chMu.RLock()
+	sinkCh := b.sinkCh
+	b.chMu.RUnlock()
+
 	for {
-		rec, ok := <-b.sinkCh
+		rec, ok := <-sinkCh
 		if !ok {
+			// The channel is closed by Resize after passing its replacement,
+			// or by Close.
+			select {
+			case sinkCh = <-b.nextCh:
+				continue
+			default:
+			}
 			b.inner.Close()
 			break
 		}
 
-		b.inner.Handle(rec)
+		if err := handle(b.inner, rec); err != nil {
+			if b.OnError != nil {
+				b.OnError(err)
+			} else {
+				fmt.Fprintf(os.Stderr, "SinkHandler can not handle record: %s\n", err)
+			}
+		}
+		b.track(-1)
+	}
+	close(b.done)
+}
+
+// track adds n to the pending records and signals waiting flushes when
+// none are left.
+func (b *SinkHandler) track(n int) {
+	b.mu.Lock()
+	b.pending += n
+	if b.pending == 0 {
+		b.drained.Broadcast()
 	}
-	b.wg.Done()
+	b.mu.Unlock()
 }
 
 // Status reports sink capacity and length.
 func (b *SinkHandler) Status() (int, int) {
+	b.chMu.RLock()
+	defer b.chMu.RUnlock()
 	return b.bufSize, len(b.sinkCh)
 }
 
+// Resize replaces the buffer with a larger one of size records. The records
+// in the old buffer are handled before those queued afterwards, none are
+// lost. Shrinking the buffer is rejected.
+func (b *SinkHandler) Resize(size int) error {
+	b.chMu.Lock()
+	defer b.chMu.Unlock()
+
+	if b.closed {
+		return errors.New("SinkHandler closed")
+	}
+	if size < b.bufSize {
+		return fmt.Errorf("SinkHandler can not shrink buffer from %d to %d records", b.bufSize, size)
+	}
+	if size == b.bufSize {
+		return nil
+	}
+
+	sinkCh := make(chan *Record, size)
+	b.nextCh <- sinkCh
+	close(b.sinkCh)
+	b.sinkCh = sinkCh
+	b.bufSize = size
+	return nil
+}
+
+// Dropped reports the number of records dropped because the sink was full.
+func (b *SinkHandler) Dropped() uint64 {
+	return atomic.LoadUint64(&b.dropped)
+}
+
 // SetLevel sets logger level for handler.
 func (b *SinkHandler) SetLevel(l level) {
 	b.inner.SetLevel(l)
@@ -56,19 +166,100 @@ func (b *SinkHandler) SetFormatter(f Formatter) {
 	b.inner.SetFormatter(f)
 }
 
-// Handle puts rec to the sink.
-func (b *SinkHandler) Handle(rec *Record) {
-	select {
-	case b.sinkCh <- rec:
+// Handle puts a copy of rec to the sink. When the sink is full rec is handled according to the overflow policy
+// and an error is returned if a record is dropped. Errors of the inner handler are reported to OnError since
+// records are handled in the background. Records handled after Close are dropped.
+func (b *SinkHandler) Handle(r *Record) error {
+	rec := new(Record)
+	*rec = *r
+
+	b.chMu.RLock()
+	defer b.chMu.RUnlock()
+
+	if b.closed {
+		atomic.AddUint64(&b.dropped, 1)
+		return errors.New("SinkHandler closed dropping record")
+	}
+
+	b.track(1)
+	switch b.policy {
+	case Block:
+		b.sinkCh <- rec
+		return nil
+
+	case DropOldest:
+		var err error
+		for {
+			select {
+			case b.sinkCh <- rec:
+				return err
+			default:
+			}
+
+			select {
+			case <-b.sinkCh:
+				b.track(-1)
+				atomic.AddUint64(&b.dropped, 1)
+				err = errors.New("SinkHandler buffer too small dropping oldest record")
+			default:
+			}
+		}
 
 	default:
-		fmt.Fprintf(os.Stderr, "SinkHandler buffer too small dropping record\n")
+		select {
+		case b.sinkCh <- rec:
+			return nil
+
+		default:
+			b.track(-1)
+			atomic.AddUint64(&b.dropped, 1)
+			return errors.New("SinkHandler buffer too small dropping record")
+		}
 	}
 }
 
+// Flush blocks until the queued records are handled and flushes the inner handler.
+func (b *SinkHandler) Flush() error {
+	b.mu.Lock()
+	for b.pending > 0 {
+		b.drained.Wait()
+	}
+	b.mu.Unlock()
+
+	return b.inner.Flush()
+}
+
 // Close closes the sink channel, inner handler will be closed when all pending logs are processed.
-// Close blocks until all the logs are processed.
+// Close blocks until all the logs are processed. It may be called several times.
 func (b *SinkHandler) Close() {
-	close(b.sinkCh)
-	b.wg.Wait()
+	b.closeSink()
+	<-b.done
+}
+
+// closeSink closes the sink channel the first time it is called.
+func (b *SinkHandler) closeSink() {
+	b.closeOnce.Do(func() {
+		b.chMu.Lock()
+		b.closed = true
+		close(b.sinkCh)
+		b.chMu.Unlock()
+	})
+}
+
+// CloseWithTimeout is like Close but gives up waiting after d and returns an error
+// if the pending logs are not processed by then. The remaining logs are still
+// processed and the inner handler closed in the background.
+func (b *SinkHandler) CloseWithTimeout(d time.Duration) error {
+	b.closeSink()
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-b.done:
+		return nil
+	case <-t.C:
+		_, n := b.Status()
+		return fmt.Errorf("SinkHandler: %d pending records not processed within %s", n, d)
+	}
 }
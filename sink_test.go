@@ -3,6 +3,7 @@ package logger
 import (
 	"fmt"
 	"runtime"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -12,7 +13,9 @@ type LogRecorder struct {
 	Level     level
 	Formatter Formatter
 	Records   map[string][]*Record
+	Flushed   int
 	Closed    bool
+	Err       error // Err is returned by Handle
 }
 
 func NewLogRecorder() *LogRecorder {
@@ -29,12 +32,19 @@ func (b *LogRecorder) SetFormatter(f Formatter) {
 	b.Formatter = f
 }
 
-func (b *LogRecorder) Handle(rec *Record) {
+func (b *LogRecorder) Handle(r *Record) error {
+	rec := *r
 	v, ok := b.Records[rec.LoggerName]
 	if !ok {
 		v = []*Record{}
 	}
-	b.Records[rec.LoggerName] = append(v, rec)
+	b.Records[rec.LoggerName] = append(v, &rec)
+	return b.Err
+}
+
+func (b *LogRecorder) Flush() error {
+	b.Flushed++
+	return nil
 }
 
 func (b *LogRecorder) Close() {
@@ -64,7 +74,7 @@ func TestSinkHandler_Handle(t *testing.T) {
 
 	for i := 0; i < loggers; i++ {
 		if v, ok := r.Records[fmt.Sprint("logger ", i)]; !ok || len(v) != logEntries {
-			t.Errorf("Missing log records expected %d got %d", logEntries, r.Records[fmt.Sprint("logger ", i)])
+			t.Errorf("Missing log records expected %d got %d", logEntries, len(r.Records[fmt.Sprint("logger ", i)]))
 		}
 	}
 }
@@ -76,3 +86,377 @@ func doLog(l Logger, n int, wg *sync.WaitGroup) {
 	}
 	wg.Done()
 }
+
+func TestSinkHandler_RecycledRecords(t *testing.T) {
+	loggers := 8
+	logEntries := 200
+
+	r := NewLogRecorder()
+	b := NewSinkHandlerWithPolicy(r, 16, Block)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < loggers; i++ {
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			l := NewLogger(name)
+			l.SetHandler(b)
+			for j := 0; j < logEntries; j++ {
+				l.Info("%s %d", name, j)
+			}
+		}(fmt.Sprint("logger ", i))
+	}
+	wg.Wait()
+	b.Close()
+
+	for i := 0; i < loggers; i++ {
+		name := fmt.Sprint("logger ", i)
+		recs := r.Records[name]
+		if len(recs) != logEntries {
+			t.Fatalf("expected %d records got %d", logEntries, len(recs))
+		}
+		for j, rec := range recs {
+			if msg := rec.Message(); msg != fmt.Sprintf("%s %d", name, j) {
+				t.Fatalf("record of %s is overwritten: %q", name, msg)
+			}
+		}
+	}
+}
+
+// blockingRecorder is a LogRecorder blocking in Handle until released.
+type blockingRecorder struct {
+	*LogRecorder
+	started chan struct{}
+	release chan struct{}
+}
+
+func newBlockingRecorder() *blockingRecorder {
+	return &blockingRecorder{
+		LogRecorder: NewLogRecorder(),
+		started:     make(chan struct{}, 1),
+		release:     make(chan struct{}),
+	}
+}
+
+func (b *blockingRecorder) Handle(rec *Record) error {
+	select {
+	case b.started <- struct{}{}:
+	default:
+	}
+	<-b.release
+	return b.LogRecorder.Handle(rec)
+}
+
+// fillSink handles the first record and waits until the sink is processing
+// it, then handles the rest of the records.
+func fillSink(b *SinkHandler, r *blockingRecorder, n int) {
+	b.Handle(&Record{LoggerName: "sink", Args: []interface{}{0}})
+	<-r.started
+	for i := 1; i < n; i++ {
+		b.Handle(&Record{LoggerName: "sink", Args: []interface{}{i}})
+	}
+}
+
+// recordedArgs returns the first argument of each record handled by r.
+func recordedArgs(r *LogRecorder) []interface{} {
+	var args []interface{}
+	for _, rec := range r.Records["sink"] {
+		args = append(args, rec.Args[0])
+	}
+	return args
+}
+
+func TestSinkHandler_DropNewest(t *testing.T) {
+	r := newBlockingRecorder()
+	b := NewSinkHandler(r, 2)
+
+	fillSink(b, r, 4)
+	close(r.release)
+	b.Close()
+
+	if args := fmt.Sprint(recordedArgs(r.LogRecorder)); args != "[0 1 2]" {
+		t.Errorf("expected [0 1 2] got %s", args)
+	}
+	if n := b.Dropped(); n != 1 {
+		t.Errorf("expected 1 dropped record got %d", n)
+	}
+}
+
+func TestSinkHandler_DropOldest(t *testing.T) {
+	r := newBlockingRecorder()
+	b := NewSinkHandlerWithPolicy(r, 2, DropOldest)
+
+	fillSink(b, r, 4)
+	close(r.release)
+	b.Close()
+
+	if args := fmt.Sprint(recordedArgs(r.LogRecorder)); args != "[0 2 3]" {
+		t.Errorf("expected [0 2 3] got %s", args)
+	}
+	if n := b.Dropped(); n != 1 {
+		t.Errorf("expected 1 dropped record got %d", n)
+	}
+}
+
+func TestSinkHandler_Block(t *testing.T) {
+	r := newBlockingRecorder()
+	b := NewSinkHandlerWithPolicy(r, 2, Block)
+
+	fillSink(b, r, 3)
+
+	done := make(chan struct{})
+	go func() {
+		b.Handle(&Record{LoggerName: "sink", Args: []interface{}{3}})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatalf("Handle returned while the buffer is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(r.release)
+	<-done
+	b.Close()
+
+	if args := fmt.Sprint(recordedArgs(r.LogRecorder)); args != "[0 1 2 3]" {
+		t.Errorf("expected [0 1 2 3] got %s", args)
+	}
+	if n := b.Dropped(); n != 0 {
+		t.Errorf("expected no dropped records got %d", n)
+	}
+}
+
+func TestSinkHandler_Dropped(t *testing.T) {
+	r := newBlockingRecorder()
+	b := NewSinkHandler(r, 5)
+
+	fillSink(b, r, 16)
+	close(r.release)
+	b.Close()
+
+	if n := b.Dropped(); n != 10 {
+		t.Errorf("expected 10 dropped records got %d", n)
+	}
+}
+
+func TestSinkHandler_HandleError(t *testing.T) {
+	r := newBlockingRecorder()
+	b := NewSinkHandler(r, 1)
+
+	b.Handle(&Record{LoggerName: "sink", Args: []interface{}{0}})
+	<-r.started
+	if err := b.Handle(&Record{LoggerName: "sink", Args: []interface{}{1}}); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+	if err := b.Handle(&Record{LoggerName: "sink", Args: []interface{}{2}}); err == nil {
+		t.Errorf("expected an error for the dropped record")
+	}
+
+	close(r.release)
+	b.Close()
+}
+
+func TestSinkHandler_HandlerPanic(t *testing.T) {
+	h := panickingHandler{NewLogRecorder()}
+	b := NewSinkHandler(h, 4)
+
+	var errs []error
+	b.OnError = func(err error) { errs = append(errs, err) }
+	b.Handle(&Record{LoggerName: "sink", Format: "panic"})
+	b.Handle(&Record{LoggerName: "sink", Format: "after"})
+	b.Close()
+
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "handler failure") {
+		t.Errorf("expected the panic to be reported got %v", errs)
+	}
+	if recs := h.Records["sink"]; len(recs) != 1 || recs[0].Message() != "after" {
+		t.Errorf("expected the worker to survive the panic")
+	}
+}
+
+func TestSinkHandler_Flush(t *testing.T) {
+	r := newBlockingRecorder()
+	b := NewSinkHandler(r, 4)
+	fillSink(b, r, 4)
+
+	flushed := make(chan error)
+	go func() {
+		flushed <- b.Flush()
+	}()
+
+	select {
+	case <-flushed:
+		t.Fatalf("Flush returned while records are pending")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(r.release)
+	if err := <-flushed; err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+	if args := fmt.Sprint(recordedArgs(r.LogRecorder)); args != "[0 1 2 3]" {
+		t.Errorf("expected [0 1 2 3] got %s", args)
+	}
+	if r.Flushed != 1 {
+		t.Errorf("expected the inner handler to be flushed once got %d", r.Flushed)
+	}
+	if r.Closed {
+		t.Errorf("inner handler is closed by Flush")
+	}
+
+	b.Close()
+}
+
+func TestSinkHandler_CloseWithTimeout(t *testing.T) {
+	r := newBlockingRecorder()
+	b := NewSinkHandler(r, 2)
+	fillSink(b, r, 3)
+
+	start := time.Now()
+	if err := b.CloseWithTimeout(20 * time.Millisecond); err == nil {
+		t.Errorf("expected a timeout error")
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("timeout is not honored, returned after %s", d)
+	}
+
+	close(r.release)
+	<-b.done
+	if !r.Closed {
+		t.Errorf("inner handler is not closed after processing the pending records")
+	}
+}
+
+func TestSinkHandler_CloseWithTimeoutFlushed(t *testing.T) {
+	r := NewLogRecorder()
+	b := NewSinkHandler(r, 2)
+	b.Handle(&Record{LoggerName: "sink"})
+
+	if err := b.CloseWithTimeout(time.Second); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+	if !r.Closed || len(r.Records["sink"]) != 1 {
+		t.Errorf("pending records are not processed")
+	}
+}
+
+func TestSinkHandler_CloseTwice(t *testing.T) {
+	r := NewLogRecorder()
+	b := NewSinkHandler(r, 2)
+	b.Handle(&Record{LoggerName: "sink"})
+
+	b.Close()
+	b.Close()
+	if err := b.CloseWithTimeout(time.Second); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+	if !r.Closed || len(r.Records["sink"]) != 1 {
+		t.Errorf("pending records are not processed")
+	}
+}
+
+func TestSinkHandler_HandleAfterClose(t *testing.T) {
+	for _, policy := range []OverflowPolicy{DropNewest, Block, DropOldest} {
+		r := NewLogRecorder()
+		b := NewSinkHandlerWithPolicy(r, 2, policy)
+		b.Close()
+
+		if err := b.Handle(&Record{LoggerName: "sink"}); err == nil {
+			t.Errorf("policy %d: expected an error for the record handled after close", policy)
+		}
+		if n := b.Dropped(); n != 1 {
+			t.Errorf("policy %d: expected 1 dropped record got %d", policy, n)
+		}
+		if err := b.Flush(); err != nil {
+			t.Errorf("policy %d: unexpected error %s", policy, err)
+		}
+		if err := b.Resize(4); err == nil {
+			t.Errorf("policy %d: expected an error resizing a closed handler", policy)
+		}
+		if n := len(r.Records["sink"]); n != 0 {
+			t.Errorf("policy %d: expected no record handled got %d", policy, n)
+		}
+	}
+}
+
+func TestSinkHandler_SetLevel(t *testing.T) {
+	r := NewLogRecorder()
+	b := NewSinkHandler(r, 1)
+	defer b.Close()
+
+	b.SetLevel(CRITICAL)
+	b.SetFormatter(&JSONFormatter{})
+
+	if r.Level != CRITICAL {
+		t.Errorf("expected level %s got %s", CRITICAL, r.Level)
+	}
+	if _, ok := r.Formatter.(*JSONFormatter); !ok {
+		t.Errorf("formatter is not set on the inner handler")
+	}
+}
+
+func BenchmarkSinkHandler(b *testing.B) {
+	h := NewSinkHandler(&countingHandler{}, 1024)
+	defer h.Close()
+	l := NewLogger("bench")
+	l.SetHandler(h)
+	l.SetErrorHook(func(error) {}) // drops are reported below
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			l.Info("benchmark %d", i)
+		}
+	})
+	h.Flush()
+	b.ReportMetric(float64(h.Dropped())/float64(b.N), "drops/op")
+}
+
+func TestSinkHandler_Resize(t *testing.T) {
+	r := &syncRecorder{LogRecorder: NewLogRecorder()}
+	b := NewSinkHandlerWithPolicy(r, 4, Block)
+
+	loggers, entries := 8, 500
+	wg := sync.WaitGroup{}
+	for i := 0; i < loggers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			l := NewLogger(fmt.Sprintf("resize%d", i))
+			l.SetHandler(b)
+			for j := 0; j < entries; j++ {
+				l.Info("%d", j)
+			}
+		}(i)
+	}
+
+	for _, size := range []int{8, 64, 64, 1024} {
+		time.Sleep(time.Millisecond)
+		if err := b.Resize(size); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Resize(16); err == nil {
+		t.Errorf("expected shrinking to be rejected")
+	}
+	if size, _ := b.Status(); size != 1024 {
+		t.Errorf("expected buffer of 1024 records got %d", size)
+	}
+
+	wg.Wait()
+	b.Close()
+
+	for i := 0; i < loggers; i++ {
+		recs := r.Records[fmt.Sprintf("resize%d", i)]
+		if len(recs) != entries {
+			t.Fatalf("expected %d records got %d", entries, len(recs))
+		}
+		for j, rec := range recs {
+			if rec.Message() != fmt.Sprint(j) {
+				t.Fatalf("expected record %d in order got %q", j, rec.Message())
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+//go:build go1.21
+// +build go1.21
+
+package logger
+
+import (
+	gocontext "context"
+	"log/slog"
+	"runtime"
+)
+
+// SlogHandler is a slog.Handler sending slog records to a Handler.
+//
+// slog levels are mapped to the nearest level of this package and slog
+// attributes become structured fields, with the keys of grouped attributes
+// joined by dots.
+type SlogHandler struct {
+	// Level is the minimum level of records reported as enabled.
+	Level level
+
+	handler Handler
+	name    string
+	fields  Fields
+	group   string // group holds the key prefix of the current group
+}
+
+// NewSlogHandler creates a new slog handler sending records to h with the given logger name.
+func NewSlogHandler(name string, h Handler) *SlogHandler {
+	return &SlogHandler{
+		Level:   DefaultLevel,
+		handler: h,
+		name:    name,
+	}
+}
+
+// Enabled reports whether records of the given slog level are logged.
+func (h *SlogHandler) Enabled(_ gocontext.Context, l slog.Level) bool {
+	return h.Level >= slogLevel(l)
+}
+
+// Handle converts the slog record to a Record and sends it to the handler.
+func (h *SlogHandler) Handle(_ gocontext.Context, r slog.Record) error {
+	rec := &Record{
+		Format:      "%s\n",
+		Args:        []interface{}{r.Message},
+		LoggerName:  h.name,
+		Level:       slogLevel(r.Level),
+		Time:        r.Time,
+		ProcessID:   pid,
+		ProcessName: pname,
+		Hostname:    hostname,
+	}
+
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		rec.Filename = frame.File
+		rec.Line = frame.Line
+		rec.Function = funcName(frame.Function)
+	}
+
+	if len(h.fields) > 0 || r.NumAttrs() > 0 {
+		rec.Fields = make(Fields, len(h.fields)+r.NumAttrs())
+		for k, v := range h.fields {
+			rec.Fields[k] = v
+		}
+		r.Attrs(func(a slog.Attr) bool {
+			addSlogAttr(rec.Fields, h.group, a)
+			return true
+		})
+	}
+
+	return handle(h.handler, rec)
+}
+
+// WithAttrs returns a new handler adding attrs to every record.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := h.clone()
+	for _, a := range attrs {
+		addSlogAttr(c.fields, c.group, a)
+	}
+	return c
+}
+
+// WithGroup returns a new handler nesting the attributes of the records in the group.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := h.clone()
+	c.group += name + "."
+	return c
+}
+
+// clone returns a copy of the handler with its own fields.
+func (h *SlogHandler) clone() *SlogHandler {
+	c := *h
+	c.fields = make(Fields, len(h.fields))
+	for k, v := range h.fields {
+		c.fields[k] = v
+	}
+	return &c
+}
+
+// addSlogAttr adds the attribute to fields, prefixing its key with group.
+func addSlogAttr(fields Fields, group string, a slog.Attr) {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		prefix := group
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range v.Group() {
+			addSlogAttr(fields, prefix, ga)
+		}
+		return
+	}
+	if a.Key == "" {
+		return
+	}
+	fields[group+a.Key] = v.Any()
+}
+
+// slogLevel maps a slog level to the nearest level of this package.
+func slogLevel(l slog.Level) level {
+	switch {
+	case l >= slog.LevelError:
+		return ERROR
+	case l >= slog.LevelWarn:
+		return WARNING
+	case l >= slog.LevelInfo:
+		return INFO
+	default:
+		return DEBUG
+	}
+}
@@ -0,0 +1,84 @@
+//go:build go1.21
+// +build go1.21
+
+package logger
+
+import (
+	gocontext "context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlogHandler(t *testing.T) {
+	r := NewLogRecorder()
+	h := NewSlogHandler("slog", r)
+	h.Level = DEBUG
+
+	l := slog.New(h).With("service", "api").WithGroup("req")
+	l.Debug("debug")
+	l.Info("info", "id", 42)
+	l.Warn("warn", slog.Group("user", "name", "bob"))
+	l.Error("error")
+
+	recs := r.Records["slog"]
+	if len(recs) != 4 {
+		t.Fatalf("expected 4 records got %d", len(recs))
+	}
+
+	for i, expected := range []level{DEBUG, INFO, WARNING, ERROR} {
+		if recs[i].Level != expected {
+			t.Errorf("expected level %s got %s", expected, recs[i].Level)
+		}
+		if recs[i].Fields["service"] != "api" {
+			t.Errorf("missing logger attribute in %v", recs[i].Fields)
+		}
+		if !strings.HasSuffix(recs[i].Filename, "slog_test.go") {
+			t.Errorf("unexpected file name %q", recs[i].Filename)
+		}
+		if recs[i].Function != "logger.TestSlogHandler" {
+			t.Errorf("unexpected function %q", recs[i].Function)
+		}
+		if recs[i].Hostname != hostname {
+			t.Errorf("unexpected hostname %q", recs[i].Hostname)
+		}
+	}
+
+	if msg := recs[1].Message(); msg != "info" {
+		t.Errorf("unexpected message %q", msg)
+	}
+	if v := recs[1].Fields["req.id"]; v != int64(42) {
+		t.Errorf("expected grouped attribute req.id got %v", recs[1].Fields)
+	}
+	if v := recs[2].Fields["req.user.name"]; v != "bob" {
+		t.Errorf("expected grouped attribute req.user.name got %v", recs[2].Fields)
+	}
+}
+
+func TestSlogHandler_Enabled(t *testing.T) {
+	r := NewLogRecorder()
+	h := NewSlogHandler("slog", r)
+	h.Level = WARNING
+
+	l := slog.New(h)
+	l.Info("filtered")
+	l.Warn("kept")
+
+	if n := len(r.Records["slog"]); n != 1 {
+		t.Errorf("expected 1 record got %d", n)
+	}
+}
+
+func TestSlogHandler_Panic(t *testing.T) {
+	h := panickingHandler{NewLogRecorder()}
+	l := slog.New(NewSlogHandler("slog", h))
+
+	if err := l.Handler().Handle(gocontext.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "panic", 0)); err == nil {
+		t.Error("expected the handler panic as an error")
+	}
+	l.Info("after")
+	if recs := h.Records["slog"]; len(recs) != 1 || recs[0].Message() != "after" {
+		t.Errorf("expected logging to continue after the panic got %v", recs)
+	}
+}
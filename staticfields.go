@@ -0,0 +1,62 @@
+package logger
+
+// StaticFieldsHandler adds a fixed set of fields, such as the service name or
+// the environment, to every record before passing it to the inner handler.
+// The fields of a record take precedence over the static fields with the
+// same key.
+type StaticFieldsHandler struct {
+	inner  Handler
+	fields Fields
+}
+
+var _ Handler = (*StaticFieldsHandler)(nil)
+
+// NewStaticFieldsHandler creates a new handler adding fields to the records
+// passed to inner. fields is copied, later changes to it have no effect.
+func NewStaticFieldsHandler(inner Handler, fields Fields) *StaticFieldsHandler {
+	static := make(Fields, len(fields))
+	for k, v := range fields {
+		static[k] = v
+	}
+	return &StaticFieldsHandler{inner: inner, fields: static}
+}
+
+// SetLevel sets logger level for inner handler.
+func (h *StaticFieldsHandler) SetLevel(l level) {
+	h.inner.SetLevel(l)
+}
+
+// SetFormatter sets logger formatter for inner handler.
+func (h *StaticFieldsHandler) SetFormatter(f Formatter) {
+	h.inner.SetFormatter(f)
+}
+
+// Handle passes a copy of rec carrying the static fields to the inner
+// handler. rec and its fields are left untouched.
+func (h *StaticFieldsHandler) Handle(rec *Record) error {
+	if len(h.fields) == 0 {
+		return h.inner.Handle(rec)
+	}
+
+	fields := make(Fields, len(h.fields)+len(rec.Fields))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	for k, v := range rec.Fields {
+		fields[k] = v
+	}
+
+	c := *rec
+	c.Fields = fields
+	return h.inner.Handle(&c)
+}
+
+// Flush flushes the inner handler.
+func (h *StaticFieldsHandler) Flush() error {
+	return h.inner.Flush()
+}
+
+// Close closes the inner handler.
+func (h *StaticFieldsHandler) Close() {
+	h.inner.Close()
+}
@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStaticFieldsHandler_Handle(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriterHandler(&buf)
+	w.SetFormatter(&LogfmtFormatter{})
+	w.SetLevel(DEBUG)
+
+	static := Fields{"service": "api", "env": "prod"}
+	h := NewStaticFieldsHandler(w, static)
+	static["env"] = "changed"
+
+	l := NewLogger("static")
+	l.SetHandler(h)
+	l.Info("started")
+	l.Info("overridden", Fields{"env": "staging", "user": "bob"})
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines got %q", buf.String())
+	}
+	if !strings.HasSuffix(lines[0], "msg=started env=prod service=api") {
+		t.Errorf("expected the static fields got %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "msg=overridden env=staging service=api user=bob") {
+		t.Errorf("expected the record fields to take precedence got %q", lines[1])
+	}
+}
+
+func TestStaticFieldsHandler_Formatters(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriterHandler(&buf)
+	l := NewLogger("static")
+	l.SetHandler(NewStaticFieldsHandler(w, Fields{"service": "api", "env": "prod"}))
+
+	l.Info("started", Fields{"env": "staging"})
+	if out := buf.String(); !strings.HasSuffix(out, "started env=staging service=api\n") {
+		t.Errorf("expected the fields in the default output got %q", out)
+	}
+
+	buf.Reset()
+	w.SetFormatter(&JSONFormatter{})
+	l.Info("started", Fields{"env": "staging"})
+	var m struct {
+		Fields map[string]string `json:"fields"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatalf("invalid JSON %q: %s", buf.String(), err)
+	}
+	if m.Fields["service"] != "api" || m.Fields["env"] != "staging" {
+		t.Errorf("expected the static fields overridden by the record in the JSON output got %v", m.Fields)
+	}
+}
+
+func TestStaticFieldsHandler_KeepsRecord(t *testing.T) {
+	r := NewLogRecorder()
+	h := NewStaticFieldsHandler(r, Fields{"service": "api"})
+
+	fields := Fields{"user": "bob"}
+	rec := &Record{LoggerName: "static", Format: "message", Fields: fields}
+	if err := h.Handle(rec); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fields) != 1 || len(rec.Fields) != 1 {
+		t.Errorf("expected the record fields to be left untouched got %v", rec.Fields)
+	}
+	recs := r.Records["static"]
+	if len(recs) != 1 || recs[0].Fields["service"] != "api" || recs[0].Fields["user"] != "bob" {
+		t.Errorf("expected the merged fields got %v", recs)
+	}
+
+	h.SetLevel(ERROR)
+	h.Close()
+	if r.Level != ERROR || !r.Closed {
+		t.Errorf("level and close are not propagated")
+	}
+}
@@ -0,0 +1,91 @@
+package logger
+
+import (
+	gocontext "context"
+)
+
+// TraceExtractor returns the trace and span IDs of the span carried by a
+// context, empty strings if there is none. When set, records logged with
+// LogCtx get them as the trace_id and span_id fields. It lets the package
+// work with OpenTelemetry without depending on it:
+//
+//	logger.TraceExtractor = func(ctx context.Context) (string, string) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		if !sc.IsValid() {
+//			return "", ""
+//		}
+//		return sc.TraceID().String(), sc.SpanID().String()
+//	}
+var TraceExtractor func(ctx gocontext.Context) (traceID, spanID string)
+
+// Names of the fields holding the IDs returned by TraceExtractor.
+const (
+	traceIDField = "trace_id"
+	spanIDField  = "span_id"
+)
+
+// contextKey is the key of the Logger stored in a context.Context.
+type contextKey struct{}
+
+// fieldsKey is the key of the Fields stored in a context.Context.
+type fieldsKey struct{}
+
+// WithFields returns a copy of ctx carrying fields, in addition to the fields
+// already carried by ctx. They are added to the records logged with ctx by
+// the Ctx methods of a Logger.
+func WithFields(ctx gocontext.Context, fields Fields) gocontext.Context {
+	merged := Fields{}
+	if parent, ok := ctx.Value(fieldsKey{}).(Fields); ok {
+		for k, v := range parent {
+			merged[k] = v
+		}
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return gocontext.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// NewContext returns a copy of ctx carrying the Logger l.
+func NewContext(ctx gocontext.Context, l Logger) gocontext.Context {
+	return gocontext.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the Logger carried by ctx, or DefaultLogger if there is none.
+func FromContext(ctx gocontext.Context) Logger {
+	if l, ok := ctx.Value(contextKey{}).(Logger); ok {
+		return l
+	}
+	return DefaultLogger
+}
+
+// contextFields returns the fields of a record logged with ctx, the fields
+// carried by ctx and its trace fields.
+func contextFields(ctx gocontext.Context) Fields {
+	if ctx == nil {
+		return nil
+	}
+
+	var fields Fields
+	if stored, ok := ctx.Value(fieldsKey{}).(Fields); ok && len(stored) > 0 {
+		fields = make(Fields, len(stored)+2)
+		for k, v := range stored {
+			fields[k] = v
+		}
+	}
+
+	if TraceExtractor == nil {
+		return fields
+	}
+	traceID, spanID := TraceExtractor(ctx)
+	if fields == nil && (traceID != "" || spanID != "") {
+		fields = Fields{}
+	}
+	if traceID != "" {
+		fields[traceIDField] = traceID
+	}
+	if spanID != "" {
+		fields[spanIDField] = spanID
+	}
+	return fields
+}
@@ -0,0 +1,164 @@
+package logger
+
+import (
+	"bytes"
+	gocontext "context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	l := NewLogger("request").New("id", 1)
+	ctx := NewContext(gocontext.Background(), l)
+
+	if FromContext(ctx) != l {
+		t.Errorf("expected the stored logger")
+	}
+
+	child, cancel := gocontext.WithCancel(ctx)
+	defer cancel()
+	if FromContext(child) != l {
+		t.Errorf("expected the logger to be inherited by derived contexts")
+	}
+}
+
+func TestFromContext_Default(t *testing.T) {
+	if FromContext(gocontext.Background()) != DefaultLogger {
+		t.Errorf("expected DefaultLogger without a stored logger")
+	}
+}
+
+// spanKey is the key of the fabricated span of the trace tests.
+type spanKey struct{}
+
+type span struct {
+	traceID, spanID string
+}
+
+func TestLogCtx_TraceExtractor(t *testing.T) {
+	defer func() { TraceExtractor = nil }()
+	TraceExtractor = func(ctx gocontext.Context) (string, string) {
+		s, ok := ctx.Value(spanKey{}).(span)
+		if !ok {
+			return "", ""
+		}
+		return s.traceID, s.spanID
+	}
+
+	r := NewLogRecorder()
+	l := NewLogger("trace")
+	l.SetHandler(r)
+
+	ctx := gocontext.WithValue(gocontext.Background(), spanKey{}, span{"4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"})
+	l.LogCtx(ctx, INFO, "traced")
+	l.New("child").LogCtx(ctx, WARNING, "traced child")
+	l.LogCtx(gocontext.Background(), INFO, "untraced")
+	l.LogCtx(ctx, DEBUG, "filtered")
+
+	recs := r.Records["trace"]
+	if len(recs) != 3 {
+		t.Fatalf("expected 3 records got %d", len(recs))
+	}
+	for _, rec := range recs[:2] {
+		if rec.Fields["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || rec.Fields["span_id"] != "00f067aa0ba902b7" {
+			t.Errorf("expected the trace fields on %q got %v", rec.Message(), rec.Fields)
+		}
+	}
+	if recs[1].Message() != "[child] traced child" {
+		t.Errorf("unexpected message %q", recs[1].Message())
+	}
+	if recs[2].Fields != nil {
+		t.Errorf("expected no fields without a span got %v", recs[2].Fields)
+	}
+}
+
+func TestLogCtx_TraceFormatted(t *testing.T) {
+	defer func() { TraceExtractor = nil }()
+	TraceExtractor = func(ctx gocontext.Context) (string, string) {
+		s, ok := ctx.Value(spanKey{}).(span)
+		if !ok {
+			return "", ""
+		}
+		return s.traceID, s.spanID
+	}
+	ctx := gocontext.WithValue(gocontext.Background(), spanKey{}, span{"4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"})
+
+	var buf bytes.Buffer
+	h := NewWriterHandler(&buf)
+	l := NewLogger("trace")
+	l.SetHandler(h)
+
+	h.SetFormatter(&JSONFormatter{})
+	l.InfoCtx(ctx, "traced")
+	var j struct {
+		Fields map[string]string `json:"fields"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &j); err != nil {
+		t.Fatalf("invalid JSON %q: %s", buf.String(), err)
+	}
+	if j.Fields["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" || j.Fields["span_id"] != "00f067aa0ba902b7" {
+		t.Errorf("expected the trace fields in the JSON output got %q", buf.String())
+	}
+
+	buf.Reset()
+	h.SetFormatter(&GCPFormatter{ProjectID: "my-project"})
+	l.InfoCtx(WithFields(ctx, Fields{"user": "bob"}), "traced")
+	var g map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &g); err != nil {
+		t.Fatalf("invalid JSON %q: %s", buf.String(), err)
+	}
+	if g["logging.googleapis.com/trace"] != "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736" ||
+		g["logging.googleapis.com/spanId"] != "00f067aa0ba902b7" {
+		t.Errorf("expected the trace and span of the entry got %q", buf.String())
+	}
+	if _, ok := g["trace_id"]; ok || g["user"] != "bob" {
+		t.Errorf("expected only the other fields in the payload got %q", buf.String())
+	}
+
+	buf.Reset()
+	h.SetFormatter(&GCPFormatter{})
+	l.InfoCtx(ctx, "traced")
+	if !strings.Contains(buf.String(), `"logging.googleapis.com/trace":"4bf92f3577b34da6a3ce929d0e0e4736"`) {
+		t.Errorf("expected the raw trace ID without project got %q", buf.String())
+	}
+}
+
+func TestLogger_CtxMethods(t *testing.T) {
+	r := NewLogRecorder()
+	l := NewLogger("ctx")
+	l.SetLevel(DEBUG)
+	l.SetHandler(r)
+
+	ctx := WithFields(gocontext.Background(), Fields{"request": "r1", "user": "bob"})
+	ctx = WithFields(ctx, Fields{"user": "alice"})
+
+	l.CriticalCtx(ctx, "critical")
+	l.ErrorCtx(ctx, "error")
+	l.WarningCtx(ctx, "warning")
+	l.NoticeCtx(ctx, "notice")
+	l.InfoCtx(ctx, "info")
+	l.DebugCtx(ctx, "debug")
+	l.New("child").InfoCtx(ctx, "child")
+	l.Info("without context")
+
+	recs := r.Records["ctx"]
+	if len(recs) != 8 {
+		t.Fatalf("expected 8 records got %d", len(recs))
+	}
+	for i, lv := range []level{CRITICAL, ERROR, WARNING, NOTICE, INFO, DEBUG, INFO} {
+		rec := recs[i]
+		if rec.Level != lv {
+			t.Errorf("expected %s got %s", lv, rec.Level)
+		}
+		if rec.Fields["request"] != "r1" || rec.Fields["user"] != "alice" {
+			t.Errorf("expected the context fields on %q got %v", rec.Message(), rec.Fields)
+		}
+	}
+	if msg := recs[6].Message(); msg != "[child] child" {
+		t.Errorf("unexpected message %q", msg)
+	}
+	if recs[7].Fields != nil {
+		t.Errorf("unexpected fields %v", recs[7].Fields)
+	}
+}
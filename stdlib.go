@@ -0,0 +1,83 @@
+package logger
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"strings"
+	"sync"
+)
+
+// stdlibWriter is an io.Writer logging each written line with a Logger.
+type stdlibWriter struct {
+	logger Logger
+	level  level
+}
+
+// StdlibWriter returns an io.Writer logging each line written to it with l
+// at the given level. It lets libraries writing to an io.Writer or a
+// *log.Logger feed into l.
+func StdlibWriter(l Logger, lv level) io.Writer {
+	return &stdlibWriter{logger: l, level: lv}
+}
+
+// NewStdlibLogger returns a *log.Logger writing its output to l at the given level.
+func NewStdlibLogger(l Logger, lv level) *log.Logger {
+	return log.New(StdlibWriter(l, lv), "", 0)
+}
+
+func (w *stdlibWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+		w.logger.Log(w.level, "%s", line)
+	}
+	return len(p), nil
+}
+
+// lineWriter is the io.Writer returned by Logger.Writer. Unlike stdlibWriter
+// it buffers partial lines, so a line written in several calls is logged as
+// a single record.
+type lineWriter struct {
+	logger Logger
+	level  level
+
+	mu  sync.Mutex
+	buf []byte // buf holds the partial line not yet logged
+}
+
+// Write logs the complete lines of the buffered partial line followed by p,
+// and buffers the rest.
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	start := 0
+	for {
+		i := bytes.IndexByte(w.buf[start:], '\n')
+		if i < 0 {
+			break
+		}
+		w.logger.Log(w.level, "%s", string(w.buf[start:start+i]))
+		start += i + 1
+	}
+	w.buf = w.buf[:copy(w.buf, w.buf[start:])]
+	return len(p), nil
+}
+
+// Flush logs the buffered partial line, if any, as a record of its own.
+func (w *lineWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		w.logger.Log(w.level, "%s", string(w.buf))
+		w.buf = w.buf[:0]
+	}
+	return nil
+}
+
+// Close logs the buffered partial line so that a last line without a
+// newline is not lost.
+func (w *lineWriter) Close() error {
+	return w.Flush()
+}
@@ -0,0 +1,126 @@
+package logger
+
+import (
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestNewStdlibLogger(t *testing.T) {
+	r := NewLogRecorder()
+	l := NewLogger("stdlib")
+	l.SetHandler(r)
+
+	std := NewStdlibLogger(l, WARNING)
+	_, _, line, _ := runtime.Caller(0)
+	std.Printf("hello %d%%", 100) // must be reported at line+1
+	std.Print("first\nsecond")
+
+	recs := r.Records["stdlib"]
+	if len(recs) != 3 {
+		t.Fatalf("expected 3 records got %d", len(recs))
+	}
+
+	for i, expected := range []string{"hello 100%", "first", "second"} {
+		if recs[i].Level != WARNING {
+			t.Errorf("expected level %s got %s", WARNING, recs[i].Level)
+		}
+		if msg := recs[i].Message(); msg != expected {
+			t.Errorf("expected %q got %q", expected, msg)
+		}
+	}
+
+	if !strings.HasSuffix(recs[0].Filename, "stdlib_test.go") || recs[0].Line != line+1 {
+		t.Errorf("expected stdlib_test.go:%d got %s:%d", line+1, recs[0].Filename, recs[0].Line)
+	}
+}
+
+func TestLogger_Writer(t *testing.T) {
+	r := NewLogRecorder()
+	l := NewLogger("writer")
+	l.SetHandler(r)
+
+	w := l.Writer(ERROR)
+	for _, s := range []string{"par", "tial", " line\nsecond\n", "\n", "third\nunterminated"} {
+		if n, err := io.WriteString(w, s); n != len(s) || err != nil {
+			t.Fatalf("expected %d bytes written got %d, %v", len(s), n, err)
+		}
+	}
+
+	expected := []string{"partial line", "second", "", "third"}
+	recs := r.Records["writer"]
+	if len(recs) != len(expected) {
+		t.Fatalf("expected %d records got %d", len(expected), len(recs))
+	}
+	for i, rec := range recs {
+		if rec.Level != ERROR || rec.Message() != expected[i] {
+			t.Errorf("expected %s %q got %s %q", ERROR, expected[i], rec.Level, rec.Message())
+		}
+	}
+
+	io.WriteString(w, " line\n")
+	recs = r.Records["writer"]
+	if msg := recs[len(recs)-1].Message(); msg != "unterminated line" {
+		t.Errorf("expected the buffered partial line got %q", msg)
+	}
+
+	io.WriteString(l.WithPrefix("http").Writer(WARNING), "TLS handshake error\n")
+	recs = r.Records["writer"]
+	if msg := recs[len(recs)-1].Message(); msg != "[http] TLS handshake error" {
+		t.Errorf("expected the context prefix got %q", msg)
+	}
+}
+
+func TestLogger_WriterClose(t *testing.T) {
+	r := NewLogRecorder()
+	l := NewLogger("writer")
+	l.SetHandler(r)
+
+	w := l.Writer(WARNING)
+	io.WriteString(w, "first\nno newline")
+	if err := w.(io.Closer).Close(); err != nil {
+		t.Fatal(err)
+	}
+	w.(io.Closer).Close()
+
+	recs := r.Records["writer"]
+	if len(recs) != 2 || recs[1].Message() != "no newline" || recs[1].Level != WARNING {
+		t.Errorf("expected the partial line to be logged on close got %v", recs)
+	}
+}
+
+func TestLogger_WriterCustomLevel(t *testing.T) {
+	const TRACE = DEBUG + 1
+	RegisterLevel(TRACE, "TRACE", BLUE)
+	defer func() {
+		levelsMu.Lock()
+		delete(levelNames, TRACE)
+		delete(levelColors, TRACE)
+		levelsMu.Unlock()
+	}()
+
+	r := NewLogRecorder()
+	l := NewLogger("writer")
+	l.SetHandler(r)
+	l.SetLevel(TRACE)
+
+	io.WriteString(l.Writer(TRACE), "traced\n")
+	io.WriteString(StdlibWriter(l, TRACE), "traced too\n")
+
+	recs := r.Records["writer"]
+	if len(recs) != 2 {
+		t.Fatalf("expected 2 records got %d", len(recs))
+	}
+	for _, rec := range recs {
+		if rec.Level != TRACE {
+			t.Errorf("expected level %s got %s", TRACE, rec.Level)
+		}
+	}
+
+	l.SetLevel(DEBUG)
+	io.WriteString(l.Writer(TRACE), "filtered\n")
+	if n := len(r.Records["writer"]); n != 2 {
+		t.Errorf("expected the trace record to be filtered by a debug logger got %d records", n)
+	}
+}
@@ -2,7 +2,16 @@
 
 package logger
 
-import "log/syslog"
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/syslog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
 
 // SyslogHandler sends the logger output to syslog.
 type SyslogHandler struct {
@@ -23,30 +32,148 @@ func NewSyslogHandler(tag string) (*SyslogHandler, error) {
 	}, nil
 }
 
-func (b *SyslogHandler) Handle(rec *Record) {
+func (b *SyslogHandler) Handle(rec *Record) error {
 	message := b.BaseHandler.FilterAndFormat(rec)
 	if message == "" {
-		return
+		return nil
 	}
 
+	// Custom levels are sent with the severity of the nearest level.
 	var fn func(string) error
-	switch rec.Level {
-	case CRITICAL:
+	switch severity(rec.Level) {
+	case 2:
 		fn = b.w.Crit
-	case ERROR:
+	case 3:
 		fn = b.w.Err
-	case WARNING:
+	case 4:
 		fn = b.w.Warning
-	case NOTICE:
+	case 5:
 		fn = b.w.Notice
-	case INFO:
+	case 6:
 		fn = b.w.Info
-	case DEBUG:
+	default:
 		fn = b.w.Debug
 	}
-	fn(message)
+	return fn(message)
 }
 
 func (b *SyslogHandler) Close() {
 	b.w.Close()
 }
+
+// RFC5424Handler sends the logger output to a local or remote syslog daemon
+// using RFC 5424 framing.
+type RFC5424Handler struct {
+	*BaseHandler
+	mu       sync.Mutex
+	network  string
+	addr     string
+	facility syslog.Priority
+	tag      string
+	hostname string
+	conn     net.Conn
+}
+
+// NewRFC5424Handler creates a new syslog handler connecting to addr on the
+// given network, e.g. "udp" and "localhost:514". An empty network connects
+// to the local syslog daemon. Records are sent with the given facility and
+// tag as application name.
+func NewRFC5424Handler(network, addr string, facility syslog.Priority, tag string) (*RFC5424Handler, error) {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	if tag == "" {
+		tag = "-"
+	}
+
+	h := &RFC5424Handler{
+		BaseHandler: NewBaseHandler(),
+		network:     network,
+		addr:        addr,
+		facility:    facility & facilityMask,
+		tag:         tag,
+		hostname:    hostname,
+	}
+	if err := h.connect(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// facilityMask masks the facility bits of a syslog priority.
+const facilityMask = 0xf8
+
+// connect dials the syslog daemon.
+func (h *RFC5424Handler) connect() (err error) {
+	if h.network != "" {
+		h.conn, err = net.Dial(h.network, h.addr)
+		return err
+	}
+
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, path := range []string{"/dev/log", "/var/run/syslog", "/var/run/log"} {
+			if h.conn, err = net.Dial(network, path); err == nil {
+				h.network = network
+				h.addr = path
+				return nil
+			}
+		}
+	}
+	return errors.New("logger: unix syslog delivery error")
+}
+
+func (h *RFC5424Handler) Handle(rec *Record) error {
+	message := h.BaseHandler.FilterAndFormat(rec)
+	if message == "" {
+		return nil
+	}
+
+	msg := fmt.Sprintf("<%d>1 %s %s %s %d - - %s",
+		h.facility|syslogSeverity(rec.Level),
+		rec.Time.Format("2006-01-02T15:04:05.000000Z07:00"),
+		h.hostname,
+		h.tag,
+		rec.ProcessID,
+		strings.TrimSuffix(message, "\n"),
+	)
+
+	// Stream transports need octet counting to delimit the messages.
+	switch h.network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.conn != nil {
+		if _, err := io.WriteString(h.conn, msg); err == nil {
+			return nil
+		}
+		h.conn.Close()
+		h.conn = nil
+	}
+
+	// Retry once with a new connection.
+	if err := h.connect(); err != nil {
+		return fmt.Errorf("RFC5424Handler can not connect to syslog: %s", err)
+	}
+	_, err := io.WriteString(h.conn, msg)
+	return err
+}
+
+// Close closes the connection to the syslog daemon.
+func (h *RFC5424Handler) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.conn != nil {
+		h.conn.Close()
+		h.conn = nil
+	}
+}
+
+// syslogSeverity maps a level to the syslog severity.
+func syslogSeverity(l level) syslog.Priority {
+	return syslog.Priority(severity(l))
+}
@@ -0,0 +1,112 @@
+// +build !windows,!plan9
+
+package logger
+
+import (
+	"log/syslog"
+	"net"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSyslogHandler_CustomLevels(t *testing.T) {
+	const TRACE = DEBUG + 1
+	RegisterLevel(TRACE, "TRACE", BLUE)
+	defer func() {
+		levelsMu.Lock()
+		delete(levelNames, TRACE)
+		delete(levelColors, TRACE)
+		levelsMu.Unlock()
+	}()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	w, err := syslog.Dial("udp", conn.LocalAddr().String(), syslog.LOG_INFO|syslog.LOG_USER, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &SyslogHandler{BaseHandler: NewBaseHandler(), w: w}
+	defer h.Close()
+	h.SetLevel(TRACE)
+
+	severities := map[level]int{
+		CRITICAL - 1: 2,
+		ERROR:        3,
+		TRACE:        7,
+	}
+
+	re := regexp.MustCompile(`^<(\d+)>`)
+	buf := make([]byte, 1024)
+	for l, severity := range severities {
+		if err := h.Handle(&Record{Format: "message\n", Level: l, Time: time.Now()}); err != nil {
+			t.Fatalf("%s: %s", l, err)
+		}
+
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m := re.FindStringSubmatch(string(buf[:n]))
+		if m == nil {
+			t.Fatalf("unexpected message %q", buf[:n])
+		}
+		pri, _ := strconv.Atoi(m[1])
+		if pri != int(syslog.LOG_USER)|severity {
+			t.Errorf("expected priority %d for %s got %d", int(syslog.LOG_USER)|severity, l, pri)
+		}
+	}
+}
+
+func TestRFC5424Handler_Handle(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	h, err := NewRFC5424Handler("udp", conn.LocalAddr().String(), syslog.LOG_LOCAL0, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	h.SetLevel(DEBUG)
+	h.SetFormatter(&messageFormatter{})
+
+	severities := map[level]int{
+		CRITICAL: 2,
+		ERROR:    3,
+		WARNING:  4,
+		NOTICE:   5,
+		INFO:     6,
+		DEBUG:    7,
+	}
+
+	re := regexp.MustCompile(`^<(\d+)>1 \S+ \S+ app \d+ - - message$`)
+	buf := make([]byte, 1024)
+	for l, severity := range severities {
+		h.Handle(&Record{Format: "message\n", Level: l, Time: time.Now()})
+
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		m := re.FindStringSubmatch(string(buf[:n]))
+		if m == nil {
+			t.Fatalf("unexpected message %q", buf[:n])
+		}
+		pri, _ := strconv.Atoi(m[1])
+		if pri != int(syslog.LOG_LOCAL0)|severity {
+			t.Errorf("expected priority %d for %s got %d", int(syslog.LOG_LOCAL0)|severity, l, pri)
+		}
+	}
+}
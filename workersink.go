@@ -0,0 +1,180 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// WorkerSinkHandler is a SinkHandler draining its buffer with several
+// goroutines, for inner handlers too slow to keep up with a single one. The
+// inner handler must be safe for concurrent use.
+//
+// Records are handled in no particular order unless the handler is ordered,
+// in which case the records of a logger are always handled by the same
+// worker, in order.
+type WorkerSinkHandler struct {
+	dropped uint64 // dropped counts dropped records, kept first for 64-bit alignment
+	inner   Handler
+	bufSize int
+	wg      sync.WaitGroup
+
+	chMu      sync.RWMutex   // chMu guards the closing of sinkChs
+	sinkChs   []chan *Record // sinkChs holds a channel per worker when ordered, a shared one otherwise
+	closed    bool           // closed is set once the handler is closed
+	closeOnce sync.Once
+
+	// OnError receives the errors of the inner handler, including its
+	// panics. They are printed to stderr if it is nil. It must be set
+	// before the handler is used.
+	OnError func(error)
+
+	mu      sync.Mutex
+	drained *sync.Cond // drained is signaled when no record is pending
+	pending int        // pending counts the records queued or being handled
+}
+
+var _ Handler = (*WorkerSinkHandler)(nil)
+
+// NewWorkerSinkHandler creates a new sink handler handling records with the
+// given number of workers. When ordered is set each worker has its own buffer
+// of bufSize records and the records of a logger go to the same worker.
+// Incoming records are dropped when the buffer is full. A nil inner handler
+// discards the records.
+func NewWorkerSinkHandler(inner Handler, bufSize, workers int, ordered bool) *WorkerSinkHandler {
+	if workers < 1 {
+		workers = 1
+	}
+	if inner == nil {
+		inner = DiscardHandler
+	}
+
+	b := &WorkerSinkHandler{
+		inner:   inner,
+		bufSize: bufSize,
+	}
+	b.drained = sync.NewCond(&b.mu)
+
+	chans := 1
+	if ordered {
+		chans = workers
+	}
+	for i := 0; i < chans; i++ {
+		b.sinkChs = append(b.sinkChs, make(chan *Record, bufSize))
+	}
+
+	b.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go b.process(b.sinkChs[i%chans])
+	}
+
+	return b
+}
+
+// process reads log records from sinkCh and calls inner log handler to write it.
+func (b *WorkerSinkHandler) process(sinkCh chan *Record) {
+	defer b.wg.Done()
+	for rec := range sinkCh {
+		if err := handle(b.inner, rec); err != nil {
+			if b.OnError != nil {
+				b.OnError(err)
+			} else {
+				fmt.Fprintf(os.Stderr, "WorkerSinkHandler can not handle record: %s\n", err)
+			}
+		}
+		b.track(-1)
+	}
+}
+
+// track adds n to the pending records and signals waiting flushes when
+// none are left.
+func (b *WorkerSinkHandler) track(n int) {
+	b.mu.Lock()
+	b.pending += n
+	if b.pending == 0 {
+		b.drained.Broadcast()
+	}
+	b.mu.Unlock()
+}
+
+// sinkCh returns the channel of the worker handling the records of the logger.
+func (b *WorkerSinkHandler) sinkCh(name string) chan *Record {
+	if len(b.sinkChs) == 1 {
+		return b.sinkChs[0]
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return b.sinkChs[h.Sum32()%uint32(len(b.sinkChs))]
+}
+
+// Dropped reports the number of records dropped because the sink was full.
+func (b *WorkerSinkHandler) Dropped() uint64 {
+	return atomic.LoadUint64(&b.dropped)
+}
+
+// SetLevel sets logger level for handler.
+func (b *WorkerSinkHandler) SetLevel(l level) {
+	b.inner.SetLevel(l)
+}
+
+// SetFormatter sets logger formatter for handler.
+func (b *WorkerSinkHandler) SetFormatter(f Formatter) {
+	b.inner.SetFormatter(f)
+}
+
+// Handle puts a copy of rec to the sink of its worker, or drops it and
+// returns an error if the sink is full or the handler closed.
+func (b *WorkerSinkHandler) Handle(r *Record) error {
+	rec := new(Record)
+	*rec = *r
+
+	b.chMu.RLock()
+	defer b.chMu.RUnlock()
+
+	if b.closed {
+		atomic.AddUint64(&b.dropped, 1)
+		return errors.New("WorkerSinkHandler closed dropping record")
+	}
+
+	b.track(1)
+	select {
+	case b.sinkCh(rec.LoggerName) <- rec:
+		return nil
+
+	default:
+		b.track(-1)
+		atomic.AddUint64(&b.dropped, 1)
+		return errors.New("WorkerSinkHandler buffer too small dropping record")
+	}
+}
+
+// Flush blocks until the queued records are handled and flushes the inner handler.
+func (b *WorkerSinkHandler) Flush() error {
+	b.mu.Lock()
+	for b.pending > 0 {
+		b.drained.Wait()
+	}
+	b.mu.Unlock()
+
+	return b.inner.Flush()
+}
+
+// Close closes the sink channels and blocks until all the workers processed
+// the pending logs, then closes the inner handler. It may be called several
+// times.
+func (b *WorkerSinkHandler) Close() {
+	b.closeOnce.Do(func() {
+		b.chMu.Lock()
+		b.closed = true
+		for _, ch := range b.sinkChs {
+			close(ch)
+		}
+		b.chMu.Unlock()
+
+		b.wg.Wait()
+		b.inner.Close()
+	})
+}
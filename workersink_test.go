@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// syncRecorder is a LogRecorder safe for concurrent use.
+type syncRecorder struct {
+	mu sync.Mutex
+	*LogRecorder
+}
+
+func (r *syncRecorder) Handle(rec *Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.LogRecorder.Handle(rec)
+}
+
+func handleWorkerRecords(b Handler, loggers, records int) {
+	for i := 0; i < records; i++ {
+		for j := 0; j < loggers; j++ {
+			b.Handle(&Record{LoggerName: fmt.Sprintf("logger%d", j), Args: []interface{}{i}})
+		}
+	}
+}
+
+func TestWorkerSinkHandler_Handle(t *testing.T) {
+	r := &syncRecorder{LogRecorder: NewLogRecorder()}
+	b := NewWorkerSinkHandler(r, 1000, 4, false)
+
+	handleWorkerRecords(b, 4, 100)
+	b.Close()
+
+	for j := 0; j < 4; j++ {
+		if n := len(r.Records[fmt.Sprintf("logger%d", j)]); n != 100 {
+			t.Errorf("expected 100 records of logger%d got %d", j, n)
+		}
+	}
+	if !r.Closed {
+		t.Errorf("inner handler is not closed")
+	}
+	if n := b.Dropped(); n != 0 {
+		t.Errorf("expected no dropped records got %d", n)
+	}
+}
+
+func TestWorkerSinkHandler_Ordered(t *testing.T) {
+	r := &syncRecorder{LogRecorder: NewLogRecorder()}
+	b := NewWorkerSinkHandler(r, 1000, 4, true)
+
+	handleWorkerRecords(b, 8, 100)
+	if err := b.Flush(); err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	for j := 0; j < 8; j++ {
+		recs := r.Records[fmt.Sprintf("logger%d", j)]
+		if len(recs) != 100 {
+			t.Fatalf("expected 100 records of logger%d got %d", j, len(recs))
+		}
+		for i, rec := range recs {
+			if rec.Args[0] != i {
+				t.Fatalf("records of logger%d out of order at %d: got %v", j, i, rec.Args[0])
+			}
+		}
+	}
+
+	b.Close()
+}
+
+func TestWorkerSinkHandler_CloseTwice(t *testing.T) {
+	for _, ordered := range []bool{false, true} {
+		r := &syncRecorder{LogRecorder: NewLogRecorder()}
+		b := NewWorkerSinkHandler(r, 10, 2, ordered)
+		b.Handle(&Record{LoggerName: "sink"})
+
+		b.Close()
+		b.Close()
+
+		if err := b.Handle(&Record{LoggerName: "sink"}); err == nil {
+			t.Errorf("ordered %v: expected an error for the record handled after close", ordered)
+		}
+		if n := b.Dropped(); n != 1 {
+			t.Errorf("ordered %v: expected 1 dropped record got %d", ordered, n)
+		}
+		if n := len(r.Records["sink"]); n != 1 || !r.Closed {
+			t.Errorf("ordered %v: expected the record handled before close got %d, closed %v", ordered, n, r.Closed)
+		}
+	}
+}